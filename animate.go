@@ -0,0 +1,205 @@
+package resvg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/gif"
+	"image/png"
+	"io"
+	"text/template"
+	"time"
+)
+
+// Animation is a sequence of svg frames.
+type Animation struct {
+	// Frames is the number of frames.
+	Frames int
+	// Frame returns the svg data for the frame index.
+	Frame func(i int) ([]byte, error)
+	// Delay is the delay between frames.
+	Delay time.Duration
+	// Delays are per frame delays, overriding Delay when set.
+	Delays []time.Duration
+	// LoopCount is the number of times the animation is played. When 0, the
+	// animation loops forever.
+	LoopCount int
+}
+
+// TemplateAnimation returns an animation with a frame for each of the data,
+// executing the template with the frame's data.
+func TemplateAnimation(tpl *template.Template, data []any) Animation {
+	return Animation{
+		Frames: len(data),
+		Frame:  TemplateFrame(tpl, data),
+	}
+}
+
+// TemplateFrame returns a frame func that executes the template with the
+// frame's data. Returns [ErrInvalidFrame] for frames without data.
+func TemplateFrame(tpl *template.Template, data []any) func(int) ([]byte, error) {
+	return func(i int) ([]byte, error) {
+		if i < 0 || i >= len(data) {
+			return nil, ErrInvalidFrame
+		}
+		var buf bytes.Buffer
+		if err := tpl.Execute(&buf, data[i]); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+}
+
+// delay returns the delay for the frame index.
+func (anim Animation) delay(i int) time.Duration {
+	if i < len(anim.Delays) {
+		return anim.Delays[i]
+	}
+	return anim.Delay
+}
+
+// RenderFrames renders each of the animation's frames. All frames must
+// render to the same size. Returns [ErrNoFrames] when the animation has no
+// frames or no frame func.
+func (r *Resvg) RenderFrames(anim Animation) ([]*image.RGBA, error) {
+	if anim.Frames < 1 || anim.Frame == nil {
+		return nil, ErrNoFrames
+	}
+	frames := make([]*image.RGBA, anim.Frames)
+	for i := range frames {
+		data, err := anim.Frame(i)
+		if err != nil {
+			return nil, err
+		}
+		if frames[i], err = r.Render(data); err != nil {
+			return nil, err
+		}
+		if frames[i].Rect != frames[0].Rect {
+			return nil, ErrFrameSizeMismatch
+		}
+	}
+	return frames, nil
+}
+
+// RenderGIF renders the animation as an animated gif. Frames are quantized
+// using the renderer's background, colors and dither settings.
+func (r *Resvg) RenderGIF(w io.Writer, anim Animation) error {
+	frames, err := r.RenderFrames(anim)
+	if err != nil {
+		return err
+	}
+	g := &gif.GIF{
+		Image:    make([]*image.Paletted, len(frames)),
+		Delay:    make([]int, len(frames)),
+		Disposal: make([]byte, len(frames)),
+	}
+	for i, frame := range frames {
+		g.Image[i] = toGIF(frame, r.background, r.colors, r.dither)
+		// hundredths of a second
+		g.Delay[i] = int((anim.delay(i) + 5*time.Millisecond) / (10 * time.Millisecond))
+		g.Disposal[i] = gif.DisposalBackground
+	}
+	// gif loop count is the number of repeats, and -1 plays once
+	switch {
+	case anim.LoopCount == 1:
+		g.LoopCount = -1
+	case anim.LoopCount > 1:
+		g.LoopCount = anim.LoopCount - 1
+	}
+	return gif.EncodeAll(w, g)
+}
+
+// RenderAPNG renders the animation as an animated png.
+func (r *Resvg) RenderAPNG(w io.Writer, anim Animation) error {
+	frames, err := r.RenderFrames(anim)
+	if err != nil {
+		return err
+	}
+	// all frames share the color type, so encode opaque frames with alpha
+	// when any frame is not opaque
+	opaque := true
+	for _, frame := range frames {
+		opaque = opaque && frame.Opaque()
+	}
+	width, height := frames[0].Rect.Dx(), frames[0].Rect.Dy()
+	be := binary.BigEndian
+	var enc png.Encoder
+	var buf bytes.Buffer
+	seq := uint32(0)
+	for i, frame := range frames {
+		var img image.Image = frame
+		if !opaque && frame.Opaque() {
+			img = translucentRGBA{frame}
+		}
+		ihdr, idat, err := encodeFrame(&enc, img)
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			buf.WriteString("\x89PNG\r\n\x1a\n")
+			writeChunk(&buf, "IHDR", ihdr)
+			writeChunk(&buf, "sRGB", []byte{0})
+			actl := make([]byte, 8)
+			be.PutUint32(actl[0:], uint32(len(frames)))
+			be.PutUint32(actl[4:], uint32(max(anim.LoopCount, 0)))
+			writeChunk(&buf, "acTL", actl)
+		}
+		// full frame, replacing the previous frame
+		fctl := make([]byte, 26)
+		be.PutUint32(fctl[0:], seq)
+		be.PutUint32(fctl[4:], uint32(width))
+		be.PutUint32(fctl[8:], uint32(height))
+		be.PutUint16(fctl[20:], uint16(min(anim.delay(i).Milliseconds(), 0xffff)))
+		be.PutUint16(fctl[22:], 1000)
+		writeChunk(&buf, "fcTL", fctl)
+		seq++
+		for _, data := range idat {
+			if i == 0 {
+				writeChunk(&buf, "IDAT", data)
+				continue
+			}
+			writeChunk(&buf, "fdAT", append(be.AppendUint32(nil, seq), data...))
+			seq++
+		}
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+		buf.Reset()
+	}
+	writeChunk(&buf, "IEND", nil)
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// encodeFrame encodes the image as a png, returning the IHDR chunk data and
+// the IDAT chunk payloads.
+func encodeFrame(enc *png.Encoder, img image.Image) ([]byte, [][]byte, error) {
+	var buf bytes.Buffer
+	if err := enc.Encode(&buf, img); err != nil {
+		return nil, nil, err
+	}
+	var ihdr []byte
+	var idat [][]byte
+	for b := buf.Bytes()[8:]; len(b) >= 12; {
+		n := binary.BigEndian.Uint32(b)
+		switch typ, data := string(b[4:8]), b[8:8+n]; typ {
+		case "IHDR":
+			ihdr = data
+		case "IDAT":
+			idat = append(idat, data)
+		}
+		b = b[12+n:]
+	}
+	return ihdr, idat, nil
+}
+
+// translucentRGBA is a RGBA image that is never reported as opaque, so that
+// it is encoded with an alpha channel.
+type translucentRGBA struct {
+	*image.RGBA
+}
+
+// Opaque satisfies the opaquer interface used by image/png.
+func (translucentRGBA) Opaque() bool {
+	return false
+}
@@ -0,0 +1,168 @@
+package resvg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"testing"
+	"text/template"
+	"time"
+)
+
+var frameTpl = template.Must(template.New("").Parse(`<svg width="20" height="10" xmlns="http://www.w3.org/2000/svg"><rect width="{{ . }}" height="10" fill="#f00"/></svg>`))
+
+func TestRenderGIF(t *testing.T) {
+	anim := Animation{
+		Frames:    3,
+		Frame:     TemplateFrame(frameTpl, []any{5, 10, 20}),
+		Delay:     100 * time.Millisecond,
+		Delays:    []time.Duration{500 * time.Millisecond},
+		LoopCount: 2,
+	}
+	var buf bytes.Buffer
+	if err := New().RenderGIF(&buf, anim); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	g, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(g.Image) != 3 || g.LoopCount != 1 {
+		t.Fatalf("expected 3 frames with loop count 1, got: %d %d", len(g.Image), g.LoopCount)
+	}
+	for i, exp := range []int{50, 10, 10} {
+		if g.Delay[i] != exp {
+			t.Errorf("frame %d expected delay %d, got: %d", i, exp, g.Delay[i])
+		}
+	}
+	if size := g.Image[0].Bounds().Size(); size.X != 20 || size.Y != 10 {
+		t.Errorf("expected 20x10, got: %dx%d", size.X, size.Y)
+	}
+	if _, err := New().RenderFrames(Animation{}); err != ErrNoFrames {
+		t.Errorf("expected ErrNoFrames, got: %v", err)
+	}
+	if _, err := New().RenderFrames(Animation{Frames: 1}); err != ErrNoFrames {
+		t.Errorf("expected ErrNoFrames, got: %v", err)
+	}
+	anim.Frames = 4
+	if _, err := New().RenderFrames(anim); err != ErrInvalidFrame {
+		t.Errorf("expected ErrInvalidFrame, got: %v", err)
+	}
+}
+
+func TestRenderFramesSizeMismatch(t *testing.T) {
+	anim := Animation{
+		Frames: 2,
+		Frame: func(i int) ([]byte, error) {
+			if i == 0 {
+				return redSVG, nil
+			}
+			return rectSVG, nil
+		},
+	}
+	if _, err := New().RenderFrames(anim); err != ErrFrameSizeMismatch {
+		t.Errorf("expected ErrFrameSizeMismatch, got: %v", err)
+	}
+}
+
+func TestRenderAPNG(t *testing.T) {
+	anim := TemplateAnimation(frameTpl, []any{10, 20})
+	anim.Delay = 40 * time.Millisecond
+	var buf bytes.Buffer
+	if err := New().RenderAPNG(&buf, anim); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	chunks := pngChunks(t, buf.Bytes())
+	var types []string
+	for _, c := range chunks {
+		types = append(types, c.typ)
+	}
+	exp := []string{"IHDR", "sRGB", "acTL", "fcTL", "IDAT", "fcTL", "fdAT", "IEND"}
+	if len(types) != len(exp) {
+		t.Fatalf("expected chunks %v, got: %v", exp, types)
+	}
+	for i := range exp {
+		if types[i] != exp[i] {
+			t.Fatalf("expected chunks %v, got: %v", exp, types)
+		}
+	}
+	be := binary.BigEndian
+	if n, plays := be.Uint32(chunks[2].data), be.Uint32(chunks[2].data[4:]); n != 2 || plays != 0 {
+		t.Errorf("expected 2 frames looping forever, got: %d %d", n, plays)
+	}
+	for i, c := range []pngChunk{chunks[3], chunks[5]} {
+		if seq, num, den := be.Uint32(c.data), be.Uint16(c.data[20:]), be.Uint16(c.data[22:]); int(seq) != i || num != 40 || den != 1000 {
+			t.Errorf("frame %d expected seq %d delay 40/1000, got: %d %d/%d", i, i, seq, num, den)
+		}
+	}
+	if seq := be.Uint32(chunks[6].data); seq != 2 {
+		t.Errorf("expected fdAT seq 2, got: %d", seq)
+	}
+	// default image, and the second frame as a png
+	frames, err := New().RenderFrames(anim)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	compareNRGBA(t, img, ToNRGBA(frames[0]))
+	var frame bytes.Buffer
+	frame.WriteString("\x89PNG\r\n\x1a\n")
+	writeChunk(&frame, "IHDR", chunks[0].data)
+	writeChunk(&frame, "IDAT", chunks[6].data[4:])
+	writeChunk(&frame, "IEND", nil)
+	if img, err = png.Decode(&frame); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	compareNRGBA(t, img, ToNRGBA(frames[1]))
+}
+
+func TestEncodeFrame(t *testing.T) {
+	img := gradient(37, 23)
+	for _, test := range []struct {
+		img image.Image
+		typ byte
+	}{
+		{img, 2},
+		{translucentRGBA{img}, 6},
+	} {
+		ihdr, idat, err := encodeFrame(new(png.Encoder), test.img)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if len(ihdr) != 13 || ihdr[9] != test.typ {
+			t.Fatalf("expected color type %d, got: %v", test.typ, ihdr)
+		}
+		var buf bytes.Buffer
+		buf.WriteString("\x89PNG\r\n\x1a\n")
+		writeChunk(&buf, "IHDR", ihdr)
+		for _, data := range idat {
+			writeChunk(&buf, "IDAT", data)
+		}
+		writeChunk(&buf, "IEND", nil)
+		out, err := png.Decode(&buf)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		compareNRGBA(t, out, ToNRGBA(img))
+	}
+}
+
+func compareNRGBA(t *testing.T, img image.Image, exp *image.NRGBA) {
+	t.Helper()
+	if img.Bounds() != exp.Rect {
+		t.Fatalf("expected bounds %v, got: %v", exp.Rect, img.Bounds())
+	}
+	for y := exp.Rect.Min.Y; y < exp.Rect.Max.Y; y++ {
+		for x := exp.Rect.Min.X; x < exp.Rect.Max.X; x++ {
+			if a, b := color.NRGBAModel.Convert(img.At(x, y)), exp.NRGBAAt(x, y); a != b {
+				t.Fatalf("expected %v at %d,%d, got: %v", b, x, y, a)
+			}
+		}
+	}
+}
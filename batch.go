@@ -0,0 +1,59 @@
+package resvg
+
+import (
+	"context"
+	"image"
+	"runtime"
+	"sync"
+)
+
+// Result is a batch render result.
+type Result struct {
+	Image *image.RGBA
+	Err   error
+}
+
+// RenderAll renders each of the inputs using a pool of workers sharing the
+// renderer's options and fonts, returning the results in the same order as
+// the inputs. A failed render does not stop the batch, and is reported in
+// the input's result. When workers is 0 or less, GOMAXPROCS workers are
+// used.
+//
+// When the context is done, inputs that have not started rendering are
+// reported with the context's error.
+func (r *Resvg) RenderAll(ctx context.Context, inputs [][]byte, workers int) []Result {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, len(inputs))
+	results := make([]Result, len(inputs))
+	ch := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range ch {
+				if err := ctx.Err(); err != nil {
+					results[i].Err = err
+					continue
+				}
+				results[i].Image, results[i].Err = r.Render(inputs[i])
+			}
+		}()
+	}
+	for i := range inputs {
+		ch <- i
+	}
+	close(ch)
+	wg.Wait()
+	return results
+}
+
+// RenderAll renders each of the inputs using a pool of workers. See
+// [Resvg.RenderAll].
+func RenderAll(ctx context.Context, inputs [][]byte, workers int, opts ...Option) []Result {
+	r := New(opts...)
+	defer r.Close()
+	return r.RenderAll(ctx, inputs, workers)
+}
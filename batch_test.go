@@ -0,0 +1,42 @@
+package resvg
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRenderAll(t *testing.T) {
+	inputs := [][]byte{
+		rectSVG,
+		[]byte("not svg"),
+		[]byte(`<svg width="20" height="10" xmlns="http://www.w3.org/2000/svg"><rect width="5" height="5"/></svg>`),
+		rectSVG,
+	}
+	exp := [][2]int{{400, 180}, {0, 0}, {20, 10}, {400, 180}}
+	for _, workers := range []int{0, 1, 3, 10} {
+		results := RenderAll(context.Background(), inputs, workers)
+		if len(results) != len(inputs) {
+			t.Fatalf("expected %d results, got: %d", len(inputs), len(results))
+		}
+		for i, res := range results {
+			switch {
+			case i == 1 && res.Err == nil:
+				t.Errorf("workers %d result %d expected error", workers, i)
+			case i != 1 && res.Err != nil:
+				t.Errorf("workers %d result %d expected no error, got: %v", workers, i, res.Err)
+			case i != 1:
+				if size := res.Image.Bounds().Size(); size.X != exp[i][0] || size.Y != exp[i][1] {
+					t.Errorf("workers %d result %d expected %dx%d, got: %dx%d", workers, i, exp[i][0], exp[i][1], size.X, size.Y)
+				}
+			}
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i, res := range RenderAll(ctx, inputs, 2) {
+		if !errors.Is(res.Err, context.Canceled) {
+			t.Errorf("result %d expected context.Canceled, got: %v", i, res.Err)
+		}
+	}
+}
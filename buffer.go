@@ -0,0 +1,84 @@
+package resvg
+
+import (
+	"image"
+	"image/color"
+	"sync"
+)
+
+// BufferPool is a pool of reusable RGBA images, backed by a [sync.Pool].
+type BufferPool struct {
+	pool sync.Pool
+}
+
+// NewBufferPool creates a new buffer pool.
+func NewBufferPool() *BufferPool {
+	return new(BufferPool)
+}
+
+// Get returns an image of the width and height from the pool, allocating a
+// new image when the pool has no image with sufficient capacity. The
+// contents of the returned image are undefined.
+func (p *BufferPool) Get(width, height int) *image.RGBA {
+	img, _ := p.pool.Get().(*image.RGBA)
+	if img = resizeRGBA(img, width, height); img != nil {
+		return img
+	}
+	return image.NewRGBA(image.Rect(0, 0, width, height))
+}
+
+// Put returns the image to the pool.
+func (p *BufferPool) Put(img *image.RGBA) {
+	if img != nil {
+		p.pool.Put(img)
+	}
+}
+
+// image returns img resized to the width and height and filled with the
+// renderer's background. When img is nil, the image is retrieved from the
+// renderer's buffer pool, or allocated.
+func (r *Resvg) image(img *image.RGBA, width, height int) *image.RGBA {
+	switch {
+	case img == nil && r.bufferPool != nil:
+		img = r.bufferPool.Get(width, height)
+	case img != nil:
+		img = resizeRGBA(img, width, height)
+	}
+	// newly allocated images are already transparent
+	c, alloc := color.RGBAModel.Convert(r.background).(color.RGBA), img == nil
+	if alloc {
+		img = image.NewRGBA(image.Rect(0, 0, width, height))
+	}
+	if !alloc || c != (color.RGBA{}) {
+		fillRGBA(img.Pix, c)
+	}
+	return img
+}
+
+// resizeRGBA resizes img to the width and height, reusing img's pixel
+// buffer. Returns nil when img is nil or its pixel buffer has insufficient
+// capacity.
+func resizeRGBA(img *image.RGBA, width, height int) *image.RGBA {
+	n := 4 * width * height
+	if img == nil || cap(img.Pix) < n {
+		return nil
+	}
+	img.Pix, img.Stride, img.Rect = img.Pix[:n], 4*width, image.Rect(0, 0, width, height)
+	return img
+}
+
+// fillRGBA fills pix with the color, by filling the first pixel and then
+// repeatedly doubling the filled region.
+func fillRGBA(pix []byte, c color.RGBA) {
+	if c == (color.RGBA{}) {
+		clear(pix)
+		return
+	}
+	if len(pix) < 4 {
+		return
+	}
+	pix[0], pix[1], pix[2], pix[3] = c.R, c.G, c.B, c.A
+	for n := 4; n < len(pix); n *= 2 {
+		copy(pix[n:], pix[:n])
+	}
+}
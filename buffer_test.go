@@ -0,0 +1,108 @@
+package resvg
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestFillRGBA(t *testing.T) {
+	c := color.RGBA{1, 2, 3, 4}
+	for _, n := range []int{0, 1, 2, 3, 7, 64, 100} {
+		pix := make([]byte, 4*n)
+		fillRGBA(pix, c)
+		for i := 0; i < n; i++ {
+			if p := (color.RGBA{pix[4*i], pix[4*i+1], pix[4*i+2], pix[4*i+3]}); p != c {
+				t.Fatalf("n %d expected %v at %d, got: %v", n, c, i, p)
+			}
+		}
+		fillRGBA(pix, color.RGBA{})
+		for i, b := range pix {
+			if b != 0 {
+				t.Fatalf("n %d expected 0 at %d, got: %d", n, i, b)
+			}
+		}
+	}
+}
+
+func TestRenderTo(t *testing.T) {
+	tree, err := Parse(redSVG, WithBackground(color.White))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer tree.Close()
+	dst := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	img, err := tree.RenderTo(dst, WithWidth(20), WithHeight(20))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if &img.Pix[0] != &dst.Pix[0] {
+		t.Errorf("expected pixel buffer to be reused")
+	}
+	if size := img.Bounds().Size(); size.X != 20 || size.Y != 20 || img.Stride != 80 {
+		t.Errorf("expected 20x20 with stride 80, got: %dx%d with stride %d", size.X, size.Y, img.Stride)
+	}
+	img, err = tree.RenderTo(dst, WithWidth(50), WithHeight(50))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if &img.Pix[0] == &dst.Pix[0] {
+		t.Errorf("expected new pixel buffer")
+	}
+	if size := img.Bounds().Size(); size.X != 50 || size.Y != 50 {
+		t.Errorf("expected 50x50, got: %dx%d", size.X, size.Y)
+	}
+	pool := NewBufferPool()
+	for range 3 {
+		img, err := tree.Render(WithBufferPool(pool))
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if c := img.RGBAAt(5, 5); c != (color.RGBA{255, 0, 0, 255}) {
+			t.Errorf("expected red, got: %v", c)
+		}
+		pool.Put(img)
+	}
+}
+
+func BenchmarkRender(b *testing.B) {
+	r := New(WithBackground(color.White))
+	b.ReportAllocs()
+	for range b.N {
+		if _, err := r.Render(rectSVG); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRenderTo(b *testing.B) {
+	tree, err := Parse(rectSVG, WithBackground(color.White))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer tree.Close()
+	var img *image.RGBA
+	b.ReportAllocs()
+	for range b.N {
+		if img, err = tree.RenderTo(img); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRenderBufferPool(b *testing.B) {
+	pool := NewBufferPool()
+	tree, err := Parse(rectSVG, WithBackground(color.White), WithBufferPool(pool))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer tree.Close()
+	b.ReportAllocs()
+	for range b.N {
+		img, err := tree.Render()
+		if err != nil {
+			b.Fatal(err)
+		}
+		pool.Put(img)
+	}
+}
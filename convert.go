@@ -0,0 +1,250 @@
+package resvg
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Dither is a dithering method.
+type Dither uint8
+
+// Dithering methods.
+const (
+	// DitherNone maps each pixel to the nearest color (or threshold).
+	DitherNone Dither = iota
+	// DitherFloydSteinberg diffuses quantization error to neighboring pixels.
+	DitherFloydSteinberg
+	// DitherOrdered applies an 8x8 Bayer threshold matrix.
+	DitherOrdered
+)
+
+// Flatten composites the premultiplied RGBA image over the background color,
+// returning an opaque image. Any remaining transparency in the background is
+// composited over white.
+func Flatten(img *image.RGBA, bg color.Color) *image.RGBA {
+	b := flattenColor(bg)
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	out := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		src := img.Pix[img.PixOffset(img.Rect.Min.X, img.Rect.Min.Y+y):][:4*width]
+		dst := out.Pix[y*out.Stride:][:4*width]
+		for i := 0; i < len(src); i += 4 {
+			a := 0xff - uint32(src[i+3])
+			dst[i] = src[i] + uint8((uint32(b.R)*a+0x7f)/0xff)
+			dst[i+1] = src[i+1] + uint8((uint32(b.G)*a+0x7f)/0xff)
+			dst[i+2] = src[i+2] + uint8((uint32(b.B)*a+0x7f)/0xff)
+			dst[i+3] = 0xff
+		}
+	}
+	return out
+}
+
+// flattenColor returns the color composited over white.
+func flattenColor(c color.Color) color.RGBA {
+	b := color.RGBAModel.Convert(c).(color.RGBA)
+	a := 0xff - b.A
+	return color.RGBA{b.R + a, b.G + a, b.B + a, 0xff}
+}
+
+// ToGray flattens the image over the background color, and converts it to
+// grayscale.
+func ToGray(img *image.RGBA, bg color.Color) *image.Gray {
+	flat := Flatten(img, bg)
+	out := image.NewGray(flat.Rect)
+	for i, j := 0, 0; i < len(flat.Pix); i, j = i+4, j+1 {
+		out.Pix[j] = luma(flat.Pix[i], flat.Pix[i+1], flat.Pix[i+2])
+	}
+	return out
+}
+
+// ToGray16 flattens the image over the background color, and converts it to
+// 16 bit grayscale.
+func ToGray16(img *image.RGBA, bg color.Color) *image.Gray16 {
+	flat := Flatten(img, bg)
+	out := image.NewGray16(flat.Rect)
+	for i, j := 0, 0; i < len(flat.Pix); i, j = i+4, j+2 {
+		r, g, b := uint32(flat.Pix[i])*0x101, uint32(flat.Pix[i+1])*0x101, uint32(flat.Pix[i+2])*0x101
+		// same coefficients as the standard library's color.GrayModel
+		y := (19595*r + 38470*g + 7471*b + 1<<15) >> 16
+		out.Pix[j], out.Pix[j+1] = uint8(y>>8), uint8(y)
+	}
+	return out
+}
+
+// ToMonochrome flattens the image over the background color, and converts it
+// to a 1 bit black and white paletted image. Pixels with a luminance greater
+// than or equal to the threshold are white.
+func ToMonochrome(img *image.RGBA, bg color.Color, threshold uint8, dither Dither) *image.Paletted {
+	gray := ToGray(img, bg)
+	width, height := gray.Rect.Dx(), gray.Rect.Dy()
+	out := image.NewPaletted(gray.Rect, color.Palette{color.Black, color.White})
+	t := int32(threshold)
+	switch dither {
+	case DitherFloydSteinberg:
+		// current and next row errors, offset by 1 to avoid edge checks
+		cur, next := make([]int32, width+2), make([]int32, width+2)
+		for y := range height {
+			for x := range width {
+				v := int32(gray.Pix[y*gray.Stride+x]) + cur[x+1]/16
+				q := int32(0)
+				if v >= t {
+					q, out.Pix[y*out.Stride+x] = 0xff, 1
+				}
+				e := v - q
+				cur[x+2] += e * 7
+				next[x] += e * 3
+				next[x+1] += e * 5
+				next[x+2] += e
+			}
+			cur, next = next, cur
+			clear(next)
+		}
+	case DitherOrdered:
+		for y := range height {
+			for x := range width {
+				// shift the threshold by the matrix, keeping its mean
+				if int32(gray.Pix[y*gray.Stride+x]) >= t+bayerOffset(x, y) {
+					out.Pix[y*out.Stride+x] = 1
+				}
+			}
+		}
+	default:
+		for i, v := range gray.Pix {
+			if int32(v) >= t {
+				out.Pix[i] = 1
+			}
+		}
+	}
+	return out
+}
+
+// ToPaletted flattens the image over the background color, and converts it to
+// a paletted image. When palette is nil, a palette of up to 256 colors is
+// generated with [Quantize]. Only the first 256 colors of the palette are
+// used.
+func ToPaletted(img *image.RGBA, bg color.Color, palette color.Palette, dither Dither) *image.Paletted {
+	flat := Flatten(img, bg)
+	switch {
+	case len(palette) == 0:
+		if palette = Quantize(flat, 256); len(palette) == 0 {
+			// no pixels to quantize
+			palette = color.Palette{flattenColor(bg)}
+		}
+	case len(palette) > 256:
+		palette = palette[:256]
+	}
+	out := image.NewPaletted(flat.Rect, palette)
+	switch dither {
+	case DitherFloydSteinberg:
+		draw.FloydSteinberg.Draw(out, out.Rect, flat, image.Point{})
+	case DitherOrdered:
+		// spread the matrix over the average distance between palette levels
+		spread := int32(0xff / max(cbrt(len(palette))-1, 1))
+		width, height := flat.Rect.Dx(), flat.Rect.Dy()
+		for y := range height {
+			for x := range width {
+				i, d := y*flat.Stride+4*x, bayerOffset(x, y)*spread/0xff
+				c := color.RGBA{
+					clamp8(int32(flat.Pix[i]) - d),
+					clamp8(int32(flat.Pix[i+1]) - d),
+					clamp8(int32(flat.Pix[i+2]) - d),
+					0xff,
+				}
+				out.Pix[y*out.Stride+x] = uint8(palette.Index(c))
+			}
+		}
+	default:
+		draw.Draw(out, out.Rect, flat, image.Point{}, draw.Src)
+	}
+	return out
+}
+
+// RenderGray renders svg data as a grayscale image, flattened over the
+// background.
+func (r *Resvg) RenderGray(data []byte) (*image.Gray, error) {
+	img, err := r.renderUnflattened(data)
+	if err != nil {
+		return nil, err
+	}
+	return ToGray(img, r.background), nil
+}
+
+// RenderGray16 renders svg data as a 16 bit grayscale image, flattened over
+// the background.
+func (r *Resvg) RenderGray16(data []byte) (*image.Gray16, error) {
+	img, err := r.renderUnflattened(data)
+	if err != nil {
+		return nil, err
+	}
+	return ToGray16(img, r.background), nil
+}
+
+// RenderMonochrome renders svg data as a 1 bit black and white image,
+// flattened over the background. See [ToMonochrome].
+func (r *Resvg) RenderMonochrome(data []byte, threshold uint8, dither Dither) (*image.Paletted, error) {
+	img, err := r.renderUnflattened(data)
+	if err != nil {
+		return nil, err
+	}
+	return ToMonochrome(img, r.background, threshold, dither), nil
+}
+
+// RenderPaletted renders svg data as a paletted image, flattened over the
+// background. See [ToPaletted].
+func (r *Resvg) RenderPaletted(data []byte, palette color.Palette, dither Dither) (*image.Paletted, error) {
+	img, err := r.renderUnflattened(data)
+	if err != nil {
+		return nil, err
+	}
+	return ToPaletted(img, r.background, palette, dither), nil
+}
+
+// renderUnflattened renders svg data without the background, so that the
+// To* conversions composite the background only once.
+func (r *Resvg) renderUnflattened(data []byte) (*image.RGBA, error) {
+	tree, err := r.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+	return tree.Render(WithBackground(color.Transparent))
+}
+
+// luma returns the luminance of the color, using the same coefficients as
+// the standard library's color.GrayModel.
+func luma(r, g, b uint8) uint8 {
+	return uint8((19595*uint32(r) + 38470*uint32(g) + 7471*uint32(b) + 1<<15) >> 16)
+}
+
+// bayer is an 8x8 Bayer matrix.
+var bayer = [8][8]int32{
+	{0, 32, 8, 40, 2, 34, 10, 42},
+	{48, 16, 56, 24, 50, 18, 58, 26},
+	{12, 44, 4, 36, 14, 46, 6, 38},
+	{60, 28, 52, 20, 62, 30, 54, 22},
+	{3, 35, 11, 43, 1, 33, 9, 41},
+	{51, 19, 59, 27, 49, 17, 57, 25},
+	{15, 47, 7, 39, 13, 45, 5, 37},
+	{63, 31, 55, 23, 61, 29, 53, 21},
+}
+
+// bayerOffset returns the Bayer matrix value for the position, scaled to
+// the range (-128, 128).
+func bayerOffset(x, y int) int32 {
+	return (bayer[y&7][x&7]*2+1)*0xff/128 - 0xff/2
+}
+
+// clamp8 clamps v to [0, 255].
+func clamp8(v int32) uint8 {
+	return uint8(min(max(v, 0), 0xff))
+}
+
+// cbrt returns the integer cube root of n, rounded down.
+func cbrt(n int) int {
+	i := 1
+	for (i+1)*(i+1)*(i+1) <= n {
+		i++
+	}
+	return i
+}
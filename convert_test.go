@@ -0,0 +1,143 @@
+package resvg
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestFlatten(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 3, 1))
+	img.SetRGBA(0, 0, color.RGBA{0, 0, 0, 0})
+	img.SetRGBA(1, 0, color.RGBA{128, 0, 0, 128})
+	img.SetRGBA(2, 0, color.RGBA{0, 0, 255, 255})
+	tests := []struct {
+		bg  color.Color
+		exp []color.RGBA
+	}{
+		{color.Transparent, []color.RGBA{{255, 255, 255, 255}, {255, 127, 127, 255}, {0, 0, 255, 255}}},
+		{color.Black, []color.RGBA{{0, 0, 0, 255}, {128, 0, 0, 255}, {0, 0, 255, 255}}},
+		{color.RGBA{0, 128, 0, 255}, []color.RGBA{{0, 128, 0, 255}, {128, 64, 0, 255}, {0, 0, 255, 255}}},
+	}
+	for i, test := range tests {
+		out := Flatten(img, test.bg)
+		for x, exp := range test.exp {
+			if c := out.RGBAAt(x, 0); c != exp {
+				t.Errorf("test %d expected %v at %d, got: %v", i, exp, x, c)
+			}
+		}
+	}
+}
+
+func TestToGray(t *testing.T) {
+	img := gradient(64, 8)
+	gray, gray16 := ToGray(img, color.Black), ToGray16(img, color.Black)
+	for x := range 64 {
+		exp := color.GrayModel.Convert(img.At(x, 0)).(color.Gray)
+		if c := gray.GrayAt(x, 0); c != exp {
+			t.Errorf("expected %v at %d, got: %v", exp, x, c)
+		}
+		exp16 := color.Gray16Model.Convert(img.At(x, 0)).(color.Gray16)
+		if c := gray16.Gray16At(x, 0); c != exp16 {
+			t.Errorf("expected %v at %d, got: %v", exp16, x, c)
+		}
+	}
+}
+
+func TestToMonochrome(t *testing.T) {
+	img := gradient(256, 16)
+	for _, dither := range []Dither{DitherNone, DitherFloydSteinberg, DitherOrdered} {
+		out := ToMonochrome(img, color.White, 128, dither)
+		if len(out.Palette) != 2 {
+			t.Fatalf("dither %d expected 2 colors, got: %d", dither, len(out.Palette))
+		}
+		// left edge black, right edge white, and about half white overall
+		white := 0
+		for _, v := range out.Pix {
+			white += int(v)
+		}
+		switch {
+		case out.ColorIndexAt(0, 0) != 0:
+			t.Errorf("dither %d expected black at left edge", dither)
+		case out.ColorIndexAt(255, 0) != 1:
+			t.Errorf("dither %d expected white at right edge", dither)
+		case white < len(out.Pix)*45/100 || white > len(out.Pix)*55/100:
+			t.Errorf("dither %d expected about half white, got: %d/%d", dither, white, len(out.Pix))
+		}
+	}
+}
+
+func TestToPaletted(t *testing.T) {
+	img := gradient(256, 16)
+	palette := color.Palette{color.Black, color.White, color.RGBA{255, 0, 0, 255}}
+	for _, dither := range []Dither{DitherNone, DitherFloydSteinberg, DitherOrdered} {
+		if out := ToPaletted(img, color.White, palette, dither); len(out.Palette) != 3 {
+			t.Errorf("dither %d expected 3 colors, got: %d", dither, len(out.Palette))
+		}
+	}
+	out := ToPaletted(img, color.White, nil, DitherNone)
+	if n := len(out.Palette); n < 32 || n > 256 {
+		t.Errorf("expected between 32 and 256 colors, got: %d", n)
+	}
+}
+
+func TestQuantize(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for i, c := range []color.RGBA{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}, {255, 255, 255, 255}} {
+		for j := range 4 {
+			img.SetRGBA(j, i, c)
+		}
+	}
+	palette := Quantize(img, 8)
+	if len(palette) != 4 {
+		t.Fatalf("expected 4 colors, got: %d", len(palette))
+	}
+	for y := range 4 {
+		c := img.RGBAAt(0, y)
+		if p := palette[palette.Index(c)]; p != c {
+			t.Errorf("expected %v in palette, got: %v", c, p)
+		}
+	}
+	if palette := Quantize(img, 2); len(palette) != 2 {
+		t.Errorf("expected 2 colors, got: %d", len(palette))
+	}
+}
+
+// gradient creates a horizontal black to white gradient.
+func gradient(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			v := uint8(x * 255 / (width - 1))
+			img.SetRGBA(x, y, color.RGBA{v, v, v, 255})
+		}
+	}
+	return img
+}
+
+func TestToPalettedEmpty(t *testing.T) {
+	for _, img := range []*image.RGBA{
+		image.NewRGBA(image.Rect(0, 0, 0, 0)),
+		image.NewRGBA(image.Rect(0, 0, 4, 4)),
+	} {
+		out := ToPaletted(img, color.Transparent, nil, DitherNone)
+		if len(out.Palette) == 0 {
+			t.Fatalf("expected a palette for %v", img.Rect)
+		}
+		if c := out.At(0, 0); img.Rect.Dx() != 0 && c != (color.RGBA{255, 255, 255, 255}) {
+			t.Errorf("expected white, got: %v", c)
+		}
+	}
+}
+
+func TestRenderGrayBackground(t *testing.T) {
+	data := []byte(`<svg xmlns="http://www.w3.org/2000/svg" width="4" height="4"></svg>`)
+	out, err := New(WithBackground(color.RGBA{0, 0, 0, 128})).RenderGray(data)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	// half transparent black over white, composited once
+	if v := out.GrayAt(0, 0).Y; v != 127 {
+		t.Errorf("expected 127, got: %d", v)
+	}
+}
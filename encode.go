@@ -0,0 +1,424 @@
+package resvg
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Format is an image encoding format.
+type Format uint8
+
+// Formats.
+const (
+	FormatPNG Format = iota
+	FormatJPEG
+	FormatGIF
+	FormatBMP
+	FormatTIFF
+	FormatWebP
+	FormatICO
+	FormatICNS
+	FormatSixel
+)
+
+// FormatFromExt returns the format for a file name or extension, such as
+// "out.png", ".jpg" or "tiff".
+func FormatFromExt(name string) (Format, error) {
+	ext := filepath.Ext(name)
+	if ext == "" {
+		ext = name
+	}
+	switch strings.ToLower(strings.TrimPrefix(ext, ".")) {
+	case "png":
+		return FormatPNG, nil
+	case "jpg", "jpeg", "jpe", "jfif":
+		return FormatJPEG, nil
+	case "gif":
+		return FormatGIF, nil
+	case "bmp", "dib":
+		return FormatBMP, nil
+	case "tif", "tiff":
+		return FormatTIFF, nil
+	case "webp":
+		return FormatWebP, nil
+	case "ico":
+		return FormatICO, nil
+	case "icns":
+		return FormatICNS, nil
+	case "six", "sixel":
+		return FormatSixel, nil
+	}
+	return 0, ErrUnknownFormat
+}
+
+// String satisfies the [fmt.Stringer] interface.
+func (format Format) String() string {
+	switch format {
+	case FormatPNG:
+		return "png"
+	case FormatJPEG:
+		return "jpeg"
+	case FormatGIF:
+		return "gif"
+	case FormatBMP:
+		return "bmp"
+	case FormatTIFF:
+		return "tiff"
+	case FormatWebP:
+		return "webp"
+	case FormatICO:
+		return "ico"
+	case FormatICNS:
+		return "icns"
+	case FormatSixel:
+		return "sixel"
+	}
+	return "unknown"
+}
+
+// Ext returns the file extension for the format, including the leading dot.
+func (format Format) Ext() string {
+	switch format {
+	case FormatJPEG:
+		return ".jpg"
+	case FormatTIFF:
+		return ".tif"
+	}
+	return "." + format.String()
+}
+
+// Encode encodes the rendered image to the writer in the format, using the
+// renderer's background, quality, colors, dither and output DPI settings.
+func (r *Resvg) Encode(w io.Writer, img *image.RGBA, format Format) error {
+	return r.encode(w, img, format, Metadata{})
+}
+
+// encode encodes the rendered image to the writer in the format, including
+// the metadata for formats supporting it.
+func (r *Resvg) encode(w io.Writer, img *image.RGBA, format Format, meta Metadata) error {
+	switch format {
+	case FormatPNG:
+		return r.EncodePNG(w, img, meta)
+	case FormatJPEG:
+		return encodeJPEG(w, Flatten(img, r.background), r.quality, r.outputDPI)
+	case FormatGIF:
+		return gif.Encode(w, toGIF(img, r.background, r.colors, r.dither), nil)
+	case FormatBMP:
+		return encodeBMP(w, img, r.outputDPI)
+	case FormatTIFF:
+		return encodeTIFF(w, img, r.outputDPI)
+	case FormatWebP:
+		return EncodeWebP(w, img)
+	case FormatICO:
+		return EncodeICO(w, []Icon{{Size: img.Rect.Dx(), Image: img}})
+	case FormatICNS:
+		return EncodeICNS(w, []Icon{{Size: img.Rect.Dx(), Image: img}})
+	case FormatSixel:
+		return EncodeSixel(w, img, r.background, r.colors, r.dither)
+	}
+	return ErrUnknownFormat
+}
+
+// RenderEncode renders the tree, encoding it to the writer in the format.
+// Icon formats ([FormatICO] and [FormatICNS]) are rendered at the default icon
+// sizes.
+//
+// Returns [ErrParseOption] for any format when opts affect parsing, as the
+// tree has already been parsed.
+func (t *Tree) RenderEncode(w io.Writer, format Format, opts ...Option) error {
+	return t.renderEncode(w, format, Metadata{}, opts...)
+}
+
+// renderEncode renders the tree, encoding it to the writer in the format with
+// the metadata. Icon formats are rendered at the default icon sizes.
+func (t *Tree) renderEncode(w io.Writer, format Format, meta Metadata, opts ...Option) error {
+	if len(opts) != 0 && t.r.clone(opts...).optionsKey() != t.r.optionsKey() {
+		return ErrParseOption
+	}
+	switch format {
+	case FormatICO, FormatICNS:
+		sizes, encode := DefaultICOSizes, EncodeICO
+		if format == FormatICNS {
+			sizes, encode = DefaultICNSSizes, EncodeICNS
+		}
+		icons, err := t.RenderIcons(IconSizes(sizes, opts...)...)
+		if err != nil {
+			return err
+		}
+		return encode(w, icons)
+	}
+	img, err := t.Render(opts...)
+	if err != nil {
+		return err
+	}
+	r := t.r.derive(opts...)
+	if err := r.encode(w, img, format, meta); err != nil {
+		return err
+	}
+	if r.bufferPool != nil {
+		r.bufferPool.Put(img)
+	}
+	return nil
+}
+
+// RenderEncode renders svg data, encoding it to the writer in the format.
+func (r *Resvg) RenderEncode(w io.Writer, data []byte, format Format) error {
+	tree, err := r.Parse(data)
+	if err != nil {
+		return err
+	}
+	defer tree.Close()
+	var meta Metadata
+	if format == FormatPNG {
+		meta = NewMetadata(data)
+	}
+	return tree.renderEncode(w, format, meta)
+}
+
+// ConvertFile renders the svg or svgz file src, writing it to the file dst in
+// the format for the dst file's extension.
+func (r *Resvg) ConvertFile(dst, src string) error {
+	format, err := FormatFromExt(dst)
+	if err != nil {
+		return err
+	}
+	var meta Metadata
+	if format == FormatPNG {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return err
+		}
+		meta = NewMetadata(data)
+	}
+	tree, err := r.ParseFile(src)
+	if err != nil {
+		return err
+	}
+	defer tree.Close()
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if err := tree.renderEncode(w, format, meta); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// RenderEncode renders svg data, encoding it to the writer in the format.
+func RenderEncode(w io.Writer, data []byte, format Format, opts ...Option) error {
+	r := New(opts...)
+	defer r.Close()
+	return r.RenderEncode(w, data, format)
+}
+
+// ConvertFile renders the svg or svgz file src, writing it to the file dst in
+// the format for the dst file's extension.
+func ConvertFile(dst, src string, opts ...Option) error {
+	r := New(opts...)
+	defer r.Close()
+	return r.ConvertFile(dst, src)
+}
+
+// encodeJPEG encodes the image as a jpeg, adding a JFIF segment with the dpi
+// when not 0.
+func encodeJPEG(w io.Writer, img image.Image, quality int, dpi float32) error {
+	if quality == 0 {
+		quality = jpeg.DefaultQuality
+	}
+	if dpi == 0.0 {
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return err
+	}
+	// insert the APP0 JFIF segment after the SOI marker
+	d := uint16(min(math.Round(float64(dpi)), math.MaxUint16))
+	app0 := []byte{
+		0xff, 0xe0, 0, 16,
+		'J', 'F', 'I', 'F', 0,
+		1, 2, // version
+		1, // density in dots per inch
+		byte(d >> 8), byte(d), byte(d >> 8), byte(d),
+		0, 0, // thumbnail
+	}
+	b := buf.Bytes()
+	for _, p := range [][]byte{b[:2], app0, b[2:]} {
+		if _, err := w.Write(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// toGIF converts the image to a paletted image of up to colors colors (at
+// most 256; when 0, 256 colors) for gif encoding. The image is flattened over
+// the background, unless the background is transparent, in which case mostly
+// transparent pixels are mapped to a transparent palette entry.
+func toGIF(img *image.RGBA, bg color.Color, colors int, dither Dither) *image.Paletted {
+	if colors <= 0 || colors > 256 {
+		colors = 256
+	}
+	colors = max(colors, 2)
+	transparent := false
+	if _, _, _, a := bg.RGBA(); a == 0 {
+		for i := 3; i < len(img.Pix) && !transparent; i += 4 {
+			transparent = img.Pix[i] < 0x80
+		}
+	}
+	flat := Flatten(img, bg)
+	if !transparent {
+		return ToPaletted(img, bg, Quantize(flat, colors), dither)
+	}
+	palette := append(quantizeMasked(flat, img, colors-1), color.Transparent)
+	out := ToPaletted(img, bg, palette, dither)
+	idx := uint8(len(palette) - 1)
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	for y := range height {
+		src := img.Pix[img.PixOffset(img.Rect.Min.X, img.Rect.Min.Y+y):][:4*width]
+		for x := range width {
+			if src[4*x+3] < 0x80 {
+				out.Pix[y*out.Stride+x] = idx
+			}
+		}
+	}
+	return out
+}
+
+// encodeBMP encodes the image as a 32 bit bmp with straight alpha.
+func encodeBMP(w io.Writer, img *image.RGBA, dpi float32) error {
+	buf, err := convertRGBA(img, Layout{Format: PixelBGRA, Straight: true, FlipY: true}, false)
+	if err != nil {
+		return err
+	}
+	ppm := uint32(math.Round(float64(dpi) / 0.0254))
+	const headerSize, infoSize = 14, 108
+	hdr := make([]byte, headerSize+infoSize)
+	le := binary.LittleEndian
+	// file header
+	copy(hdr, "BM")
+	le.PutUint32(hdr[2:], uint32(headerSize+infoSize+len(buf.Pix)))
+	le.PutUint32(hdr[10:], headerSize+infoSize)
+	// BITMAPV4HEADER
+	info := hdr[headerSize:]
+	le.PutUint32(info[0:], infoSize)
+	le.PutUint32(info[4:], uint32(buf.Width))
+	le.PutUint32(info[8:], uint32(buf.Height))
+	le.PutUint16(info[12:], 1)  // planes
+	le.PutUint16(info[14:], 32) // bits per pixel
+	le.PutUint32(info[16:], 3)  // BI_BITFIELDS
+	le.PutUint32(info[20:], uint32(len(buf.Pix)))
+	le.PutUint32(info[24:], ppm)
+	le.PutUint32(info[28:], ppm)
+	le.PutUint32(info[40:], 0x00ff0000) // red mask
+	le.PutUint32(info[44:], 0x0000ff00) // green mask
+	le.PutUint32(info[48:], 0x000000ff) // blue mask
+	le.PutUint32(info[52:], 0xff000000) // alpha mask
+	le.PutUint32(info[56:], 0x73524742) // LCS_sRGB
+	if _, err := w.Write(hdr); err != nil {
+		return err
+	}
+	_, err = w.Write(buf.Pix)
+	return err
+}
+
+// encodeTIFF encodes the image as a deflate compressed tiff with associated
+// (premultiplied) alpha.
+func encodeTIFF(w io.Writer, img *image.RGBA, dpi float32) error {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	// compress pixels
+	var data bytes.Buffer
+	z := zlib.NewWriter(&data)
+	for y := range height {
+		if _, err := z.Write(img.Pix[img.PixOffset(img.Rect.Min.X, img.Rect.Min.Y+y):][:4*width]); err != nil {
+			return err
+		}
+	}
+	if err := z.Close(); err != nil {
+		return err
+	}
+	if dpi == 0.0 {
+		dpi = 72.0
+	}
+	// tags, in ascending order
+	type tag struct {
+		id, typ uint16
+		count   uint32
+		value   uint32
+	}
+	const (
+		typeShort    = 3
+		typeLong     = 4
+		typeRational = 5
+		numTags      = 14
+		ifdSize      = 2 + numTags*12 + 4
+		// extra data offsets
+		bitsOffset = 8 + ifdSize
+		xresOffset = bitsOffset + 8
+		yresOffset = xresOffset + 8
+		dataOffset = yresOffset + 8
+	)
+	tags := [numTags]tag{
+		{256, typeLong, 1, uint32(width)},
+		{257, typeLong, 1, uint32(height)},
+		{258, typeShort, 4, bitsOffset}, // bits per sample
+		{259, typeShort, 1, 8},          // adobe deflate compression
+		{262, typeShort, 1, 2},          // rgb
+		{273, typeLong, 1, dataOffset},  // strip offsets
+		{277, typeShort, 1, 4},          // samples per pixel
+		{278, typeLong, 1, uint32(height)},
+		{279, typeLong, 1, uint32(data.Len())},
+		{282, typeRational, 1, xresOffset},
+		{283, typeRational, 1, yresOffset},
+		{284, typeShort, 1, 1}, // contiguous planar config
+		{296, typeShort, 1, 2}, // resolution unit inch
+		{338, typeShort, 1, 1}, // associated alpha
+	}
+	le := binary.LittleEndian
+	hdr := make([]byte, dataOffset)
+	copy(hdr, "II*\x00")
+	le.PutUint32(hdr[4:], 8)
+	le.PutUint16(hdr[8:], numTags)
+	for i, t := range tags {
+		b := hdr[10+12*i:]
+		le.PutUint16(b[0:], t.id)
+		le.PutUint16(b[2:], t.typ)
+		le.PutUint32(b[4:], t.count)
+		if t.typ == typeShort && t.count == 1 {
+			le.PutUint16(b[8:], uint16(t.value))
+		} else {
+			le.PutUint32(b[8:], t.value)
+		}
+	}
+	for i := range 4 {
+		le.PutUint16(hdr[bitsOffset+2*i:], 8)
+	}
+	res := uint32(math.Round(float64(dpi) * 100))
+	le.PutUint32(hdr[xresOffset:], res)
+	le.PutUint32(hdr[xresOffset+4:], 100)
+	le.PutUint32(hdr[yresOffset:], res)
+	le.PutUint32(hdr[yresOffset+4:], 100)
+	if _, err := w.Write(hdr); err != nil {
+		return err
+	}
+	_, err := w.Write(data.Bytes())
+	return err
+}
@@ -0,0 +1,205 @@
+package resvg
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFormatFromExt(t *testing.T) {
+	tests := []struct {
+		name string
+		exp  Format
+	}{
+		{"out.png", FormatPNG},
+		{"OUT.JPG", FormatJPEG},
+		{".jpeg", FormatJPEG},
+		{"gif", FormatGIF},
+		{"a/b.bmp", FormatBMP},
+		{"c.tif", FormatTIFF},
+		{"tiff", FormatTIFF},
+	}
+	for _, test := range tests {
+		format, err := FormatFromExt(test.name)
+		if err != nil {
+			t.Fatalf("%s expected no error, got: %v", test.name, err)
+		}
+		if format != test.exp {
+			t.Errorf("%s expected %v, got: %v", test.name, test.exp, format)
+		}
+		if f, _ := FormatFromExt(format.Ext()); f != format {
+			t.Errorf("%s expected ext %q to be %v, got: %v", test.name, format.Ext(), format, f)
+		}
+	}
+	if _, err := FormatFromExt("out.svg"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("expected ErrUnknownFormat, got: %v", err)
+	}
+}
+
+func TestRenderEncode(t *testing.T) {
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	for _, format := range []Format{FormatPNG, FormatJPEG, FormatGIF} {
+		t.Run(format.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := RenderEncode(&buf, redSVG, format, WithWidth(20), WithHeight(10), WithQuality(95)); err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			img, name, err := image.Decode(&buf)
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if name != format.String() {
+				t.Errorf("expected %s, got: %s", format, name)
+			}
+			if size := img.Bounds().Size(); size.X != 20 || size.Y != 10 {
+				t.Errorf("expected 20x10, got: %dx%d", size.X, size.Y)
+			}
+			c := color.RGBAModel.Convert(img.At(5, 5)).(color.RGBA)
+			if c.R < 0xf0 || c.G > 0x10 || c.B > 0x10 || c.A != 0xff {
+				t.Errorf("expected %v, got: %v", red, c)
+			}
+		})
+	}
+}
+
+func TestTreeRenderEncodeParseOption(t *testing.T) {
+	tree, err := New().Parse(redSVG)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer tree.Close()
+	for _, format := range []Format{FormatPNG, FormatJPEG, FormatICO, FormatICNS} {
+		if err := tree.RenderEncode(io.Discard, format, WithDPI(300)); !errors.Is(err, ErrParseOption) {
+			t.Errorf("%s expected ErrParseOption, got: %v", format, err)
+		}
+		if err := tree.RenderEncode(io.Discard, format, WithBackground(color.White)); err != nil {
+			t.Errorf("%s expected no error, got: %v", format, err)
+		}
+	}
+}
+
+func TestEncodeJPEGDensity(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderEncode(&buf, redSVG, FormatJPEG, WithOutputDPI(300)); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	b := buf.Bytes()
+	if !bytes.HasPrefix(b, []byte{0xff, 0xd8, 0xff, 0xe0, 0, 16, 'J', 'F', 'I', 'F', 0}) {
+		t.Fatalf("expected JFIF segment, got: % x", b[:min(len(b), 11)])
+	}
+	if b[13] != 1 || binary.BigEndian.Uint16(b[14:]) != 300 || binary.BigEndian.Uint16(b[16:]) != 300 {
+		t.Errorf("expected 300 dpi density, got: % x", b[13:18])
+	}
+	if _, _, err := image.Decode(&buf); err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+}
+
+func TestEncodeGIFTransparent(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	img.SetRGBA(1, 1, color.RGBA{0xff, 0, 0, 0xff})
+	out := toGIF(img, color.Transparent, 0, DitherNone)
+	if _, _, _, a := out.At(0, 0).RGBA(); a != 0 {
+		t.Errorf("expected transparent, got alpha: %d", a)
+	}
+	if c := color.RGBAModel.Convert(out.At(1, 1)).(color.RGBA); c != (color.RGBA{0xff, 0, 0, 0xff}) {
+		t.Errorf("expected red, got: %v", c)
+	}
+}
+
+func TestEncodeBMP(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	img.SetRGBA(0, 0, color.RGBA{0x80, 0, 0, 0x80})
+	var buf bytes.Buffer
+	if err := encodeBMP(&buf, img, 96); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	b, le := buf.Bytes(), binary.LittleEndian
+	if string(b[:2]) != "BM" || int(le.Uint32(b[2:])) != len(b) {
+		t.Fatalf("expected bmp header, got: % x", b[:6])
+	}
+	if w, h, bpp := le.Uint32(b[18:]), le.Uint32(b[22:]), le.Uint16(b[28:]); w != 3 || h != 2 || bpp != 32 {
+		t.Errorf("expected 3x2 32 bpp, got: %dx%d %d bpp", w, h, bpp)
+	}
+	if ppm := le.Uint32(b[38:]); ppm != 3780 {
+		t.Errorf("expected 3780 pixels per meter, got: %d", ppm)
+	}
+	// top left pixel is in the last row, as straight alpha bgra
+	pix := b[le.Uint32(b[10:]):]
+	if px := pix[12:16]; !bytes.Equal(px, []byte{0, 0, 0xff, 0x80}) {
+		t.Errorf("expected straight alpha bgra pixel, got: % x", px)
+	}
+}
+
+func TestEncodeTIFF(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	img.SetRGBA(2, 1, color.RGBA{0x10, 0x20, 0x30, 0x40})
+	var buf bytes.Buffer
+	if err := encodeTIFF(&buf, img, 300); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	b, le := buf.Bytes(), binary.LittleEndian
+	if string(b[:4]) != "II*\x00" {
+		t.Fatalf("expected tiff header, got: % x", b[:4])
+	}
+	ifd := b[le.Uint32(b[4:]):]
+	tags := make(map[uint16]uint32)
+	for i := range int(le.Uint16(ifd)) {
+		e := ifd[2+12*i:]
+		v := le.Uint32(e[8:])
+		if le.Uint16(e[2:]) == 3 && le.Uint32(e[4:]) == 1 {
+			v = uint32(le.Uint16(e[8:]))
+		}
+		tags[le.Uint16(e)] = v
+	}
+	if tags[256] != 3 || tags[257] != 2 || tags[277] != 4 || tags[338] != 1 {
+		t.Errorf("expected 3x2 rgba with associated alpha, got: %v", tags)
+	}
+	if xres := b[tags[282]:]; le.Uint32(xres)/le.Uint32(xres[4:]) != 300 {
+		t.Errorf("expected 300 dpi, got: %d/%d", le.Uint32(xres), le.Uint32(xres[4:]))
+	}
+	z, err := zlib.NewReader(bytes.NewReader(b[tags[273] : tags[273]+tags[279]]))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	pix, err := io.ReadAll(z)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !bytes.Equal(pix, img.Pix) {
+		t.Errorf("expected pixels %v, got: %v", img.Pix, pix)
+	}
+}
+
+func TestConvertFile(t *testing.T) {
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "red.svg"), filepath.Join(dir, "red.gif")
+	if err := os.WriteFile(src, redSVG, 0o644); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := ConvertFile(dst, src, WithWidth(4), WithHeight(4)); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	f, err := os.Open(dst)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer f.Close()
+	cfg, name, err := image.DecodeConfig(f)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if name != "gif" || cfg.Width != 4 || cfg.Height != 4 {
+		t.Errorf("expected 4x4 gif, got: %dx%d %s", cfg.Width, cfg.Height, name)
+	}
+	if err := ConvertFile(filepath.Join(dir, "red.xyz"), src); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("expected ErrUnknownFormat, got: %v", err)
+	}
+}
@@ -0,0 +1,248 @@
+package resvg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+)
+
+// Default icon sizes.
+var (
+	// DefaultICOSizes are the default sizes for [FormatICO].
+	DefaultICOSizes = []int{16, 32, 48, 64, 128, 256}
+	// DefaultICNSSizes are the default sizes for [FormatICNS].
+	DefaultICNSSizes = []int{16, 32, 64, 128, 256, 512, 1024}
+)
+
+// IconSize is an icon size to render.
+type IconSize struct {
+	// Size is the width and height of the icon.
+	Size int
+	// Opts are the render options for the size, such as [WithSupersample],
+	// [WithDownsampleFilter] or [WithPadding]. Options that affect parsing,
+	// such as [WithShapeRendering], are only supported by
+	// [Resvg.RenderIcons].
+	Opts []Option
+}
+
+// IconSizes returns icon sizes for the sizes, with the same render options.
+func IconSizes(sizes []int, opts ...Option) []IconSize {
+	v := make([]IconSize, len(sizes))
+	for i, size := range sizes {
+		v[i] = IconSize{Size: size, Opts: opts}
+	}
+	return v
+}
+
+// Icon is a rendered icon.
+type Icon struct {
+	Size  int
+	Image *image.RGBA
+}
+
+// RenderIcons renders the tree as square icons for each of the sizes. The
+// svg is scaled to fit and centered within each icon (see [ScaleContain]).
+//
+// Returns [ErrParseOption] when a size's options affect parsing, as the tree
+// has already been parsed. Use [Resvg.RenderIcons] instead.
+func (t *Tree) RenderIcons(sizes ...IconSize) ([]Icon, error) {
+	key := t.r.optionsKey()
+	icons := make([]Icon, len(sizes))
+	for i, size := range sizes {
+		if t.r.clone(size.Opts...).optionsKey() != key {
+			return nil, ErrParseOption
+		}
+		icon, err := t.renderIcon(size)
+		if err != nil {
+			return nil, err
+		}
+		icons[i] = icon
+	}
+	return icons, nil
+}
+
+// renderIcon renders the tree as a square icon of the size.
+func (t *Tree) renderIcon(size IconSize) (Icon, error) {
+	if size.Size < 1 {
+		return Icon{}, ErrInvalidIconSize
+	}
+	opts := append([]Option{WithScaleMode(ScaleContain), WithWidth(size.Size), WithHeight(size.Size)}, size.Opts...)
+	img, err := t.Render(opts...)
+	if err != nil {
+		return Icon{}, err
+	}
+	return Icon{Size: size.Size, Image: img}, nil
+}
+
+// RenderIcons renders svg data as square icons for each of the sizes. The svg
+// is parsed once for each distinct set of parse options (such as
+// [WithShapeRendering]) among the sizes.
+func (r *Resvg) RenderIcons(data []byte, sizes ...IconSize) ([]Icon, error) {
+	trees := make(map[string]*Tree)
+	defer func() {
+		for _, tree := range trees {
+			tree.Close()
+		}
+	}()
+	key := r.optionsKey()
+	icons := make([]Icon, len(sizes))
+	for i, size := range sizes {
+		p := r.clone(size.Opts...)
+		k := p.optionsKey()
+		tree, ok := trees[k]
+		if !ok {
+			var err error
+			if k == key {
+				// share the renderer's options
+				tree, err = r.Parse(data)
+			} else {
+				tree, err = p.Parse(data)
+				_ = p.Close()
+			}
+			if err != nil {
+				return nil, err
+			}
+			trees[k] = tree
+		}
+		icon, err := tree.renderIcon(size)
+		if err != nil {
+			return nil, err
+		}
+		icons[i] = icon
+	}
+	return icons, nil
+}
+
+// EncodeICO encodes the icons as a Windows ico with png compressed entries.
+// Icons must be no larger than 256x256.
+func EncodeICO(w io.Writer, icons []Icon) error {
+	entries, err := encodeIconPNGs(icons)
+	if err != nil {
+		return err
+	}
+	le := binary.LittleEndian
+	hdr := make([]byte, 6+16*len(icons))
+	le.PutUint16(hdr[2:], 1) // icon
+	le.PutUint16(hdr[4:], uint16(len(icons)))
+	offset := len(hdr)
+	for i, icon := range icons {
+		b := icon.Image.Rect.Size()
+		if b.X > 256 || b.Y > 256 {
+			return ErrInvalidIconSize
+		}
+		e := hdr[6+16*i:]
+		// 0 is 256
+		e[0], e[1] = uint8(b.X), uint8(b.Y)
+		le.PutUint16(e[4:], 1)  // planes
+		le.PutUint16(e[6:], 32) // bits per pixel
+		le.PutUint32(e[8:], uint32(len(entries[i])))
+		le.PutUint32(e[12:], uint32(offset))
+		offset += len(entries[i])
+	}
+	if _, err := w.Write(hdr); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if _, err := w.Write(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// icnsTypes are the icns png entry types for each size. The second type is
+// the retina (@2x) type of the half size.
+var icnsTypes = map[int][2]string{
+	16:   {"icp4", ""},
+	32:   {"icp5", "ic11"},
+	64:   {"icp6", "ic12"},
+	128:  {"ic07", ""},
+	256:  {"ic08", "ic13"},
+	512:  {"ic09", "ic14"},
+	1024: {"ic10", ""},
+}
+
+// EncodeICNS encodes the icons as an Apple icns with png compressed entries.
+// Icons must be square, and one of the sizes in [DefaultICNSSizes].
+func EncodeICNS(w io.Writer, icons []Icon) error {
+	entries, err := encodeIconPNGs(icons)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	for i, icon := range icons {
+		b := icon.Image.Rect.Size()
+		types, ok := icnsTypes[b.X]
+		if !ok || b.X != b.Y {
+			return ErrInvalidIconSize
+		}
+		for _, typ := range types {
+			if typ == "" {
+				continue
+			}
+			buf.WriteString(typ)
+			_ = binary.Write(&buf, binary.BigEndian, uint32(8+len(entries[i])))
+			buf.Write(entries[i])
+		}
+	}
+	hdr := make([]byte, 8)
+	copy(hdr, "icns")
+	binary.BigEndian.PutUint32(hdr[4:], uint32(8+buf.Len()))
+	if _, err := w.Write(hdr); err != nil {
+		return err
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// encodeIconPNGs encodes each icon as a png.
+func encodeIconPNGs(icons []Icon) ([][]byte, error) {
+	if len(icons) == 0 || len(icons) > 0xffff {
+		return nil, ErrInvalidIconSize
+	}
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+	entries := make([][]byte, len(icons))
+	for i, icon := range icons {
+		var buf bytes.Buffer
+		if err := enc.Encode(&buf, icon.Image); err != nil {
+			return nil, err
+		}
+		entries[i] = buf.Bytes()
+	}
+	return entries, nil
+}
+
+// ManifestIcon is a web app manifest icon.
+type ManifestIcon struct {
+	Src   string `json:"src"`
+	Sizes string `json:"sizes"`
+	Type  string `json:"type"`
+}
+
+// WriteManifest writes a web app manifest json with the name, listing the
+// icons as png files. src is the format for each icon's file name, and is
+// passed the icon's size (for example, "icon-%d.png").
+func WriteManifest(w io.Writer, name, src string, icons []Icon) error {
+	manifest := struct {
+		Name  string         `json:"name,omitempty"`
+		Icons []ManifestIcon `json:"icons"`
+	}{
+		Name:  name,
+		Icons: make([]ManifestIcon, len(icons)),
+	}
+	for i, icon := range icons {
+		b := icon.Image.Rect.Size()
+		manifest.Icons[i] = ManifestIcon{
+			Src:   fmt.Sprintf(src, icon.Size),
+			Sizes: fmt.Sprintf("%dx%d", b.X, b.Y),
+			Type:  "image/png",
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(manifest)
+}
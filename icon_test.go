@@ -0,0 +1,140 @@
+package resvg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestRenderIcons(t *testing.T) {
+	tree, err := Parse(rectSVG)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer tree.Close()
+	icons, err := tree.RenderIcons(append(IconSizes([]int{16, 32}, WithSupersample(4)), IconSize{Size: 256})...)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	for i, exp := range []int{16, 32, 256} {
+		if size := icons[i].Image.Rect.Size(); icons[i].Size != exp || size.X != exp || size.Y != exp {
+			t.Errorf("icon %d expected %dx%d, got: %dx%d", i, exp, exp, size.X, size.Y)
+		}
+	}
+	if _, err := tree.RenderIcons(IconSize{}); err != ErrInvalidIconSize {
+		t.Errorf("expected ErrInvalidIconSize, got: %v", err)
+	}
+	// ico
+	var buf bytes.Buffer
+	if err := EncodeICO(&buf, icons); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	b, le := buf.Bytes(), binary.LittleEndian
+	if le.Uint16(b[2:]) != 1 || le.Uint16(b[4:]) != 3 {
+		t.Fatalf("expected ico with 3 entries, got: % x", b[:6])
+	}
+	for i, exp := range []int{16, 32, 0} {
+		e := b[6+16*i:]
+		if int(e[0]) != exp || int(e[1]) != exp {
+			t.Errorf("entry %d expected %dx%d, got: %dx%d", i, exp, exp, e[0], e[1])
+		}
+		img, err := png.Decode(bytes.NewReader(b[le.Uint32(e[12:]):][:le.Uint32(e[8:])]))
+		if err != nil {
+			t.Fatalf("entry %d expected no error, got: %v", i, err)
+		}
+		if img.Bounds().Dx() != icons[i].Size {
+			t.Errorf("entry %d expected %d, got: %d", i, icons[i].Size, img.Bounds().Dx())
+		}
+	}
+	// icns
+	buf.Reset()
+	if err := EncodeICNS(&buf, icons); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	b, be := buf.Bytes(), binary.BigEndian
+	if string(b[:4]) != "icns" || int(be.Uint32(b[4:])) != len(b) {
+		t.Fatalf("expected icns header, got: % x", b[:8])
+	}
+	var types []string
+	for p := b[8:]; len(p) >= 8; p = p[be.Uint32(p[4:]):] {
+		types = append(types, string(p[:4]))
+		if _, err := png.Decode(bytes.NewReader(p[8:be.Uint32(p[4:])])); err != nil {
+			t.Fatalf("%s expected no error, got: %v", p[:4], err)
+		}
+	}
+	if exp := []string{"icp4", "icp5", "ic11", "ic08", "ic13"}; !slices.Equal(types, exp) {
+		t.Errorf("expected %v, got: %v", exp, types)
+	}
+	if err := EncodeICNS(&buf, nil); err != ErrInvalidIconSize {
+		t.Errorf("expected ErrInvalidIconSize, got: %v", err)
+	}
+	// manifest
+	buf.Reset()
+	if err := WriteManifest(&buf, "app", "icon-%d.png", icons); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	var manifest struct {
+		Name  string
+		Icons []ManifestIcon
+	}
+	if err := json.Unmarshal(buf.Bytes(), &manifest); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if exp := (ManifestIcon{"icon-256.png", "256x256", "image/png"}); manifest.Name != "app" || len(manifest.Icons) != 3 || manifest.Icons[2] != exp {
+		t.Errorf("expected manifest with %v, got: %+v", exp, manifest)
+	}
+}
+
+func TestRenderIconsParseOptions(t *testing.T) {
+	ClearOptionsCache()
+	crisp := []Option{WithShapeRendering(ShapeRenderingCrispEdges)}
+	sizes := []IconSize{{16, crisp}, {32, nil}, {48, crisp}}
+	tree, err := Parse(rectSVG)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer tree.Close()
+	if _, err := tree.RenderIcons(sizes...); !errors.Is(err, ErrParseOption) {
+		t.Errorf("expected ErrParseOption, got: %v", err)
+	}
+	icons, err := New().RenderIcons(rectSVG, sizes...)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	for i, exp := range []int{16, 32, 48} {
+		if size := icons[i].Image.Rect.Size(); icons[i].Size != exp || size.X != exp || size.Y != exp {
+			t.Errorf("icon %d expected %dx%d, got: %dx%d", i, exp, exp, size.X, size.Y)
+		}
+	}
+	// crisp sizes are parsed with their own options
+	optionsCache.Lock()
+	_, ok := optionsCache.m[New(crisp...).optionsKey()]
+	optionsCache.Unlock()
+	if !ok {
+		t.Errorf("expected options with crisp edges shape rendering")
+	}
+}
+
+func TestConvertFileICO(t *testing.T) {
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "rect.svg"), filepath.Join(dir, "favicon.ico")
+	if err := os.WriteFile(src, rectSVG, 0o644); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := ConvertFile(dst, src); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	b, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if n := binary.LittleEndian.Uint16(b[4:]); int(n) != len(DefaultICOSizes) {
+		t.Errorf("expected %d entries, got: %d", len(DefaultICOSizes), n)
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/xo/resvg/internal/webptest
+
+go 1.22
+
+require (
+	github.com/xo/resvg v0.0.0
+	golang.org/x/image v0.24.0
+)
+
+replace github.com/xo/resvg => ../..
@@ -0,0 +1,86 @@
+// Package webptest checks webp encoding against the golang.org/x/image
+// decoder. It is a separate module, so that the dependency is only required
+// for testing.
+package webptest
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/xo/resvg"
+	"golang.org/x/image/webp"
+)
+
+func TestEncodeWebPDecode(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	random := func(width, height int, transparent float64) *image.RGBA {
+		img := image.NewRGBA(image.Rect(0, 0, width, height))
+		for y := range height {
+			for x := range width {
+				if rnd.Float64() < transparent {
+					continue
+				}
+				img.Set(x, y, color.NRGBA{uint8(rnd.Intn(256)), uint8(rnd.Intn(256)), uint8(rnd.Intn(256)), uint8(rnd.Intn(256))})
+			}
+		}
+		return img
+	}
+	flat := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	for i := 0; i < len(flat.Pix); i += 4 {
+		copy(flat.Pix[i:], []byte{0x20, 0x40, 0x80, 0xff})
+	}
+	// few colors, repeating with a period that is not a multiple of the width
+	stripes := image.NewRGBA(image.Rect(0, 0, 123, 77))
+	for i := 0; i < len(stripes.Pix); i += 4 {
+		copy(stripes.Pix[i:], []byte{uint8(i / 4 % 7 * 30), 0, uint8(i / 4 % 3 * 100), 0xff})
+	}
+	data, err := os.ReadFile("../../testdata/rect.svg")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	rendered, err := resvg.Render(data)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	tests := []struct {
+		name string
+		img  *image.RGBA
+	}{
+		{"one", image.NewRGBA(image.Rect(0, 0, 1, 1))},
+		{"random", random(97, 61, 0)},
+		{"random large", random(256, 256, 0)},
+		{"mostly transparent", random(120, 80, 0.9)},
+		{"flat", flat},
+		{"stripes", stripes},
+		{"rendered", rendered},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := resvg.EncodeWebP(&buf, test.img); err != nil {
+			t.Fatalf("%s expected no error, got: %v", test.name, err)
+		}
+		img, err := webp.Decode(&buf)
+		if err != nil {
+			t.Fatalf("%s expected no error decoding, got: %v", test.name, err)
+		}
+		exp := resvg.ToNRGBA(test.img)
+		nrgba, ok := img.(*image.NRGBA)
+		switch {
+		case !ok:
+			t.Fatalf("%s expected *image.NRGBA, got: %T", test.name, img)
+		case nrgba.Rect != exp.Rect:
+			t.Fatalf("%s expected bounds %v, got: %v", test.name, exp.Rect, nrgba.Rect)
+		}
+		for y := range exp.Rect.Dy() {
+			for x := range exp.Rect.Dx() {
+				if a, b := exp.NRGBAAt(x, y), nrgba.NRGBAAt(x, y); a != b {
+					t.Fatalf("%s expected %v at %d,%d, got: %v", test.name, a, x, y, b)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,569 @@
+package resvg
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// WorkerEnv is the environment variable used to start a process as an
+// isolated render worker. See [Isolated].
+const WorkerEnv = "RESVG_WORKER"
+
+func init() {
+	if os.Getenv(WorkerEnv) == "" {
+		return
+	}
+	if err := serveWorker(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "resvg worker: %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// Isolated renders svgs in worker subprocesses, isolating the calling
+// process from crashes and runaway memory use in resvg.
+//
+// By default, workers are started by re-executing the current binary with
+// [WorkerEnv] set, which is handled by this package's init. Use
+// [WithWorkerCommand] to use a different binary that imports this package.
+//
+// Workers are reused between renders, and a worker that crashes or exceeds
+// the render timeout is killed and replaced. Workers communicate over stdin
+// and stdout, so the worker binary must not otherwise write to stdout before
+// this package's init.
+type Isolated struct {
+	cfg          workerConfig
+	timeout      time.Duration
+	startTimeout time.Duration
+	command      []string
+	maxIdle      int
+	idle         []*worker
+	closed       bool
+	mu           sync.Mutex
+	memoryLimit  uint64
+}
+
+// NewIsolated creates a new isolated renderer. Render timeouts, worker start
+// timeouts and worker memory limits are set with [WithTimeout],
+// [WithStartTimeout] and [WithMemoryLimit].
+func NewIsolated(opts ...Option) *Isolated {
+	r := New(opts...)
+	defer r.Close()
+	startTimeout := r.startTimeout
+	if startTimeout == 0 {
+		startTimeout = DefaultStartTimeout
+	}
+	return &Isolated{
+		cfg:          r.workerConfig(),
+		timeout:      r.timeout,
+		startTimeout: startTimeout,
+		command:      r.workerCommand,
+		maxIdle:      runtime.GOMAXPROCS(0),
+		memoryLimit:  r.memoryLimit,
+	}
+}
+
+// Render renders svg data as a RGBA image in a worker subprocess.
+//
+// Returns [ErrRenderTimeout] when the render does not complete within the
+// timeout, or [ErrWorkerCrashed] when the worker exits unexpectedly or does
+// not start within the start timeout.
+func (iso *Isolated) Render(data []byte) (*image.RGBA, error) {
+	return iso.RenderContext(context.Background(), data)
+}
+
+// RenderContext renders svg data as a RGBA image in a worker subprocess.
+// When the context is done before rendering completes, the worker is killed
+// and the context's error is returned.
+func (iso *Isolated) RenderContext(ctx context.Context, data []byte) (*image.RGBA, error) {
+	res, err := iso.do(ctx, workerRequest{Data: data})
+	if err != nil {
+		return nil, err
+	}
+	return &image.RGBA{
+		Pix:    res.Pix,
+		Stride: 4 * res.Width,
+		Rect:   image.Rect(0, 0, res.Width, res.Height),
+	}, nil
+}
+
+// ParseConfig parses the svg in a worker subprocess, returning an image
+// config.
+func (iso *Isolated) ParseConfig(data []byte) (image.Config, error) {
+	return iso.ParseConfigContext(context.Background(), data)
+}
+
+// ParseConfigContext parses the svg in a worker subprocess, returning an
+// image config. When the context is done before parsing completes, the
+// worker is killed and the context's error is returned.
+func (iso *Isolated) ParseConfigContext(ctx context.Context, data []byte) (image.Config, error) {
+	res, err := iso.do(ctx, workerRequest{Data: data, ConfigOnly: true})
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{
+		ColorModel: color.RGBAModel,
+		Width:      res.Width,
+		Height:     res.Height,
+	}, nil
+}
+
+// Close stops all idle workers. Workers with in-flight renders are stopped
+// when their render completes.
+func (iso *Isolated) Close() error {
+	iso.mu.Lock()
+	idle := iso.idle
+	iso.idle, iso.closed = nil, true
+	iso.mu.Unlock()
+	for _, w := range idle {
+		w.stop()
+	}
+	return nil
+}
+
+// do sends the request to a worker, returning the response.
+func (iso *Isolated) do(ctx context.Context, req workerRequest) (*workerResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	w, err := iso.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res, err := w.do(ctx, req, iso.timeout)
+	if err != nil {
+		return nil, err
+	}
+	iso.put(w)
+	return res.result()
+}
+
+// get returns an idle worker, or starts a new worker.
+func (iso *Isolated) get(ctx context.Context) (*worker, error) {
+	iso.mu.Lock()
+	if iso.closed {
+		iso.mu.Unlock()
+		return nil, ErrClosed
+	}
+	if n := len(iso.idle); n != 0 {
+		w := iso.idle[n-1]
+		iso.idle = iso.idle[:n-1]
+		iso.mu.Unlock()
+		return w, nil
+	}
+	iso.mu.Unlock()
+	return startWorker(ctx, iso.command, iso.cfg, iso.memoryLimit, iso.startTimeout)
+}
+
+// put returns the worker to the idle pool.
+func (iso *Isolated) put(w *worker) {
+	iso.mu.Lock()
+	if !iso.closed && len(iso.idle) < iso.maxIdle {
+		iso.idle = append(iso.idle, w)
+		w = nil
+	}
+	iso.mu.Unlock()
+	if w != nil {
+		w.stop()
+	}
+}
+
+// worker is a render worker subprocess.
+type worker struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	enc    *gob.Encoder
+	dec    *gob.Decoder
+	stderr *headBuffer
+	exited chan struct{}
+	err    error
+}
+
+// startWorker starts a worker subprocess, sending it the config and waiting
+// for it to be ready. Returns [ErrWorkerCrashed] when the worker is not ready
+// within the timeout.
+func startWorker(ctx context.Context, command []string, cfg workerConfig, memoryLimit uint64, timeout time.Duration) (*worker, error) {
+	if len(command) == 0 {
+		name, err := os.Executable()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrWorkerCrashed, err)
+		}
+		command = []string{name}
+	}
+	w := &worker{
+		cmd:    exec.Command(command[0], command[1:]...),
+		stderr: &headBuffer{n: 1024},
+		exited: make(chan struct{}),
+	}
+	w.cmd.Env = append(os.Environ(), WorkerEnv+"=1", workerMemoryLimitEnv+"="+strconv.FormatUint(memoryLimit, 10))
+	w.cmd.Stderr = w.stderr
+	stdin, err := w.cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := w.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := w.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrWorkerCrashed, err)
+	}
+	go func() {
+		w.err = w.cmd.Wait()
+		close(w.exited)
+	}()
+	w.stdin, w.enc, w.dec = stdin, gob.NewEncoder(stdin), gob.NewDecoder(stdout)
+	switch _, err := w.do(ctx, cfg, timeout); {
+	case errors.Is(err, ErrRenderTimeout):
+		return nil, fmt.Errorf("%w: not ready after %v", ErrWorkerCrashed, timeout)
+	case err != nil:
+		return nil, err
+	}
+	return w, nil
+}
+
+// do sends v to the worker and waits for the response, killing the worker
+// when the timeout is exceeded or the context is done.
+func (w *worker) do(ctx context.Context, v any, timeout time.Duration) (*workerResponse, error) {
+	type result struct {
+		res *workerResponse
+		err error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		if err := w.enc.Encode(v); err != nil {
+			ch <- result{err: err}
+			return
+		}
+		res := new(workerResponse)
+		if err := w.dec.Decode(res); err != nil {
+			ch <- result{err: err}
+			return
+		}
+		ch <- result{res: res}
+	}()
+	var expired <-chan time.Time
+	if timeout != 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		expired = t.C
+	}
+	select {
+	case res := <-ch:
+		if res.err != nil {
+			return nil, w.crashed(res.err)
+		}
+		return res.res, nil
+	case <-expired:
+		w.kill()
+		return nil, ErrRenderTimeout
+	case <-ctx.Done():
+		w.kill()
+		return nil, ctx.Err()
+	}
+}
+
+// crashed waits for the worker to exit, returning a [ErrWorkerCrashed] error
+// with the worker's exit status and stderr.
+func (w *worker) crashed(err error) error {
+	w.kill()
+	if w.err != nil {
+		err = w.err
+	}
+	if s := bytes.TrimSpace(w.stderr.Bytes()); len(s) != 0 {
+		return fmt.Errorf("%w: %v: %s", ErrWorkerCrashed, err, s)
+	}
+	return fmt.Errorf("%w: %v", ErrWorkerCrashed, err)
+}
+
+// stop gracefully stops the worker.
+func (w *worker) stop() {
+	_ = w.stdin.Close()
+	select {
+	case <-w.exited:
+	case <-time.After(time.Second):
+		w.kill()
+	}
+}
+
+// kill kills the worker, waiting for it to exit.
+func (w *worker) kill() {
+	_ = w.cmd.Process.Kill()
+	<-w.exited
+}
+
+// workerMemoryLimitEnv is the environment variable used to pass the memory
+// limit to a worker.
+const workerMemoryLimitEnv = "RESVG_WORKER_MEMORY_LIMIT"
+
+// serveWorker serves render requests read from in, writing responses to out.
+func serveWorker(in io.Reader, out io.Writer) error {
+	if s := os.Getenv(workerMemoryLimitEnv); s != "" && s != "0" {
+		limit, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		if err := setMemoryLimit(limit); err != nil {
+			return err
+		}
+	}
+	dec, enc := gob.NewDecoder(in), gob.NewEncoder(out)
+	var cfg workerConfig
+	if err := dec.Decode(&cfg); err != nil {
+		return err
+	}
+	r := cfg.resvg()
+	// build options before signaling ready, so loading fonts is counted
+	// against the start timeout, not a render's timeout
+	r.once.Do(r.buildOpts)
+	if err := enc.Encode(workerResponse{}); err != nil {
+		return err
+	}
+	for {
+		var req workerRequest
+		switch err := dec.Decode(&req); {
+		case errors.Is(err, io.EOF):
+			return nil
+		case err != nil:
+			return err
+		}
+		var res workerResponse
+		if req.ConfigOnly {
+			cfg, err := r.ParseConfig(req.Data)
+			res.Width, res.Height = cfg.Width, cfg.Height
+			res.setErr(err)
+		} else {
+			img, err := r.Render(req.Data)
+			if img != nil {
+				res.Width, res.Height, res.Pix = img.Rect.Dx(), img.Rect.Dy(), img.Pix
+			}
+			res.setErr(err)
+		}
+		if err := enc.Encode(res); err != nil {
+			return err
+		}
+	}
+}
+
+// workerConfig is the renderer config sent to a worker.
+type workerConfig struct {
+	LoadSystemFonts bool
+	ResourcesDir    string
+	DPI             float32
+	FontFamily      string
+	FontSize        float32
+	SerifFamily     string
+	SansSerifFamily string
+	CursiveFamily   string
+	FantasyFamily   string
+	MonospaceFamily string
+	Languages       []string
+	ShapeRendering  ShapeRendering
+	TextRendering   TextRendering
+	ImageRendering  ImageRendering
+	Fonts           [][]byte
+	FontFiles       []string
+	Background      color.RGBA
+	Width           uint
+	Height          uint
+	ScaleMode       ScaleMode
+	Align           Align
+	Padding         [4]int
+	PhysicalWidth   float32
+	PhysicalHeight  float32
+	OutputDPI       float32
+	Transform       *Transform
+	UserTransform   *Transform
+	ExpandCanvas    bool
+	NodeStrokeBBox  bool
+	Trim            bool
+	TrimMargin      float32
+	EmptyError      bool
+	Supersample     int
+	Filter          Filter
+}
+
+// workerConfig returns the worker config for the renderer.
+func (r *Resvg) workerConfig() workerConfig {
+	return workerConfig{
+		LoadSystemFonts: r.loadSystemFonts,
+		ResourcesDir:    r.resourcesDir,
+		DPI:             r.dp,
+		FontFamily:      r.fontFamily,
+		FontSize:        r.fontSize,
+		SerifFamily:     r.serifFamily,
+		SansSerifFamily: r.sansSerifFamily,
+		CursiveFamily:   r.cursiveFamily,
+		FantasyFamily:   r.fantasyFamily,
+		MonospaceFamily: r.monospaceFamily,
+		Languages:       r.languages,
+		ShapeRendering:  r.shapeRendering,
+		TextRendering:   r.textRendering,
+		ImageRendering:  r.imageRendering,
+		Fonts:           r.fonts,
+		FontFiles:       r.fontFiles,
+		Background:      color.RGBAModel.Convert(r.background).(color.RGBA),
+		Width:           r.width,
+		Height:          r.height,
+		ScaleMode:       r.scaleMode,
+		Align:           r.align,
+		Padding:         r.padding,
+		PhysicalWidth:   r.physicalWidth,
+		PhysicalHeight:  r.physicalHeight,
+		OutputDPI:       r.outputDPI,
+		Transform:       r.transform,
+		UserTransform:   r.userTransform,
+		ExpandCanvas:    r.expandCanvas,
+		NodeStrokeBBox:  r.nodeStrokeBBox,
+		Trim:            r.trim,
+		TrimMargin:      r.trimMargin,
+		EmptyError:      r.emptyError,
+		Supersample:     r.supersample,
+		Filter:          r.filter,
+	}
+}
+
+// resvg creates a renderer for the worker config.
+func (cfg workerConfig) resvg() *Resvg {
+	return New(func(r *Resvg) {
+		r.loadSystemFonts = cfg.LoadSystemFonts
+		r.resourcesDir = cfg.ResourcesDir
+		r.dp = cfg.DPI
+		r.fontFamily = cfg.FontFamily
+		r.fontSize = cfg.FontSize
+		r.serifFamily = cfg.SerifFamily
+		r.sansSerifFamily = cfg.SansSerifFamily
+		r.cursiveFamily = cfg.CursiveFamily
+		r.fantasyFamily = cfg.FantasyFamily
+		r.monospaceFamily = cfg.MonospaceFamily
+		r.languages = cfg.Languages
+		r.shapeRendering = cfg.ShapeRendering
+		r.textRendering = cfg.TextRendering
+		r.imageRendering = cfg.ImageRendering
+		r.fonts = cfg.Fonts
+		r.fontFiles = cfg.FontFiles
+		r.background = cfg.Background
+		r.width = cfg.Width
+		r.height = cfg.Height
+		r.scaleMode = cfg.ScaleMode
+		r.align = cfg.Align
+		r.padding = cfg.Padding
+		r.physicalWidth = cfg.PhysicalWidth
+		r.physicalHeight = cfg.PhysicalHeight
+		r.outputDPI = cfg.OutputDPI
+		r.transform = cfg.Transform
+		r.userTransform = cfg.UserTransform
+		r.expandCanvas = cfg.ExpandCanvas
+		r.nodeStrokeBBox = cfg.NodeStrokeBBox
+		r.trim = cfg.Trim
+		r.trimMargin = cfg.TrimMargin
+		r.emptyError = cfg.EmptyError
+		r.supersample = cfg.Supersample
+		r.filter = cfg.Filter
+	})
+}
+
+// workerRequest is a render request sent to a worker.
+type workerRequest struct {
+	Data       []byte
+	ConfigOnly bool
+}
+
+// workerResponse is a render response sent by a worker.
+type workerResponse struct {
+	Width  int
+	Height int
+	Pix    []byte
+	// ErrNo and Sentinel are the resvg or package error wrapped by Err
+	ErrNo    int
+	Sentinel string
+	Err      string
+}
+
+// setErr sets the response's error.
+func (res *workerResponse) setErr(err error) {
+	if err == nil {
+		return
+	}
+	res.Err = err.Error()
+	var errNo ErrNo
+	var sentinel Error
+	switch {
+	case errors.As(err, &errNo):
+		res.ErrNo = int(errNo)
+	case errors.As(err, &sentinel):
+		res.Sentinel = string(sentinel)
+	}
+}
+
+// result returns the response, or the response's error.
+func (res *workerResponse) result() (*workerResponse, error) {
+	var err error
+	switch {
+	case res.ErrNo != 0:
+		err = ErrNo(res.ErrNo)
+	case res.Sentinel != "":
+		err = Error(res.Sentinel)
+	case res.Err != "":
+		return nil, errors.New(res.Err)
+	default:
+		return res, nil
+	}
+	if res.Err == err.Error() {
+		return nil, err
+	}
+	return nil, &workerError{msg: res.Err, err: err}
+}
+
+// workerError is an error returned by a worker, wrapping a resvg or package
+// error.
+type workerError struct {
+	msg string
+	err error
+}
+
+// Error satisfies the [error] interface.
+func (err *workerError) Error() string {
+	return err.msg
+}
+
+// Unwrap returns the wrapped error.
+func (err *workerError) Unwrap() error {
+	return err.err
+}
+
+// headBuffer is a writer that retains the first n bytes written.
+type headBuffer struct {
+	n   int
+	buf []byte
+	mu  sync.Mutex
+}
+
+// Write satisfies the [io.Writer] interface.
+func (b *headBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if n := b.n - len(b.buf); n > 0 {
+		b.buf = append(b.buf, p[:min(n, len(p))]...)
+	}
+	return len(p), nil
+}
+
+// Bytes returns the retained bytes.
+func (b *headBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]byte(nil), b.buf...)
+}
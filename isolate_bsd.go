@@ -0,0 +1,18 @@
+//go:build freebsd || dragonfly
+
+package resvg
+
+import (
+	"math"
+	"syscall"
+)
+
+// setMemoryLimit sets the data memory limit of the current process.
+func setMemoryLimit(limit uint64) error {
+	// rlimits are signed
+	v := int64(min(limit, math.MaxInt64))
+	return syscall.Setrlimit(syscall.RLIMIT_DATA, &syscall.Rlimit{
+		Cur: v,
+		Max: v,
+	})
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package resvg
+
+// setMemoryLimit is a no-op on systems without rlimits.
+func setMemoryLimit(uint64) error {
+	return nil
+}
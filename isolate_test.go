@@ -0,0 +1,133 @@
+package resvg
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestIsolated(t *testing.T) {
+	iso := NewIsolated(WithScaleMode(ScaleBestFit), WithWidth(200), WithTimeout(30*time.Second))
+	defer iso.Close()
+	for i := range 3 {
+		img, err := iso.Render(rectSVG)
+		if err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		if size := img.Bounds().Size(); size.X != 200 || size.Y != 90 {
+			t.Errorf("test %d expected 200x90, got: %dx%d", i, size.X, size.Y)
+		}
+	}
+	cfg, err := iso.ParseConfig(rectSVG)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.Width != 200 || cfg.Height != 90 {
+		t.Errorf("expected 200x90, got: %dx%d", cfg.Width, cfg.Height)
+	}
+	if _, err := iso.Render([]byte("not svg")); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+	if err := iso.Close(); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if _, err := iso.Render(rectSVG); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got: %v", err)
+	}
+}
+
+func TestIsolatedErrors(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires unix commands")
+	}
+	iso := NewIsolated(WithWorkerCommand("false"))
+	if _, err := iso.Render(rectSVG); !errors.Is(err, ErrWorkerCrashed) {
+		t.Errorf("expected ErrWorkerCrashed, got: %v", err)
+	}
+	// worker that signals ready, then hangs
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(workerResponse{}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	ready := filepath.Join(t.TempDir(), "ready")
+	if err := os.WriteFile(ready, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	iso = NewIsolated(WithWorkerCommand("sh", "-c", `cat "$0"; exec sleep 10`, ready), WithTimeout(50*time.Millisecond))
+	start := time.Now()
+	if _, err := iso.Render(rectSVG); !errors.Is(err, ErrRenderTimeout) {
+		t.Errorf("expected ErrRenderTimeout, got: %v", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("expected render to be stopped after timeout, took: %v", d)
+	}
+	iso = NewIsolated(WithWorkerCommand("sleep", "10"), WithTimeout(50*time.Millisecond), WithStartTimeout(50*time.Millisecond))
+	start = time.Now()
+	if _, err := iso.Render(rectSVG); !errors.Is(err, ErrWorkerCrashed) {
+		t.Errorf("expected ErrWorkerCrashed, got: %v", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("expected start to be stopped after timeout, took: %v", d)
+	}
+	iso = NewIsolated(WithWorkerCommand("sleep", "10"))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := iso.RenderContext(ctx, rectSVG); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got: %v", err)
+	}
+}
+
+func TestIsolatedStartTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires unix commands")
+	}
+	name, err := os.Executable()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	// worker start is slower than the render timeout
+	iso := NewIsolated(WithWorkerCommand("sh", "-c", `sleep 0.5 && exec "$0"`, name), WithTimeout(250*time.Millisecond))
+	defer iso.Close()
+	if _, err := iso.Render(rectSVG); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+}
+
+func TestWorkerResponseErr(t *testing.T) {
+	tests := []error{
+		ErrEmptyImage,
+		fmt.Errorf("%w: %q", ErrNodeNotFound, "a"),
+		&fs.PathError{Op: "parse", Path: "a.svg", Err: ErrNo(2)},
+		errors.New("other"),
+	}
+	for i, test := range tests {
+		// round trip through gob, as sent by a worker
+		var buf bytes.Buffer
+		var res workerResponse
+		res.setErr(test)
+		if err := gob.NewEncoder(&buf).Encode(res); err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		res = workerResponse{}
+		if err := gob.NewDecoder(&buf).Decode(&res); err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		_, err := res.result()
+		if err == nil || err.Error() != test.Error() {
+			t.Fatalf("test %d expected %v, got: %v", i, test, err)
+		}
+		for _, target := range []error{ErrEmptyImage, ErrNodeNotFound, ErrNo(2)} {
+			if errors.Is(err, target) != errors.Is(test, target) {
+				t.Errorf("test %d expected errors.Is(%v) to be %t", i, target, errors.Is(test, target))
+			}
+		}
+	}
+}
@@ -0,0 +1,15 @@
+//go:build unix && !freebsd && !dragonfly
+
+package resvg
+
+import (
+	"syscall"
+)
+
+// setMemoryLimit sets the data memory limit of the current process.
+func setMemoryLimit(limit uint64) error {
+	return syscall.Setrlimit(syscall.RLIMIT_DATA, &syscall.Rlimit{
+		Cur: limit,
+		Max: limit,
+	})
+}
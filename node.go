@@ -0,0 +1,134 @@
+package resvg
+
+/*
+#include <stdlib.h>
+
+#include "resvg.h"
+*/
+import "C"
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"unsafe"
+)
+
+// NodeExists returns true when a renderable node with the id exists in the
+// tree.
+func (t *Tree) NodeExists(id string) bool {
+	t.rw.RLock()
+	defer t.rw.RUnlock()
+	if t.tree == nil {
+		return false
+	}
+	s := C.CString(id)
+	defer C.free(unsafe.Pointer(s))
+	return bool(C.resvg_node_exists(t.tree, s))
+}
+
+// NodeTransform returns the absolute transform of the node with the id.
+func (t *Tree) NodeTransform(id string) (Transform, error) {
+	t.rw.RLock()
+	defer t.rw.RUnlock()
+	if t.tree == nil {
+		return Transform{}, ErrClosed
+	}
+	s := C.CString(id)
+	defer C.free(unsafe.Pointer(s))
+	var ts C.resvg_transform
+	if !C.resvg_get_node_transform(t.tree, s, &ts) {
+		return Transform{}, nodeNotFound(id)
+	}
+	return newTransform(ts), nil
+}
+
+// NodeBBox returns the bounding box of the node with the id, in canvas
+// coordinates.
+func (t *Tree) NodeBBox(id string) (Rect, error) {
+	t.rw.RLock()
+	defer t.rw.RUnlock()
+	if t.tree == nil {
+		return Rect{}, ErrClosed
+	}
+	return t.nodeBBox(id, false)
+}
+
+// NodeStrokeBBox returns the bounding box, including stroke, of the node with
+// the id, in canvas coordinates.
+func (t *Tree) NodeStrokeBBox(id string) (Rect, error) {
+	t.rw.RLock()
+	defer t.rw.RUnlock()
+	if t.tree == nil {
+		return Rect{}, ErrClosed
+	}
+	return t.nodeBBox(id, true)
+}
+
+// RenderNode renders the node with the id as a RGBA image, cropped to the
+// node's bounding box (or stroke bounding box, see [WithNodeStrokeBBox]).
+// The width, height, and scale mode settings are applied relative to the
+// node's bounding box.
+//
+// Any passed options override the render settings for this render only.
+func (t *Tree) RenderNode(id string, opts ...Option) (*image.RGBA, error) {
+	t.rw.RLock()
+	defer t.rw.RUnlock()
+	if t.tree == nil {
+		return nil, ErrClosed
+	}
+	r := t.r.derive(opts...)
+	// resvg positions the node's layer bounding box at the origin, which is
+	// the stroke bounding box for nodes without filters
+	strokeBBox, err := t.nodeBBox(id, true)
+	if err != nil {
+		return nil, err
+	}
+	bbox := strokeBBox
+	if !r.nodeStrokeBBox {
+		if bbox, err = t.nodeBBox(id, false); err != nil {
+			return nil, err
+		}
+	}
+	// the region is relative to the node's layer bounding box
+	width, height, ts, err := r.fit(Rect{
+		X:      bbox.X - strokeBBox.X,
+		Y:      bbox.Y - strokeBBox.Y,
+		Width:  float32(math.Ceil(float64(bbox.Width))),
+		Height: float32(math.Ceil(float64(bbox.Height))),
+	})
+	if err != nil {
+		return nil, err
+	}
+	img := r.image(nil, width, height)
+	// render
+	s := C.CString(id)
+	defer C.free(unsafe.Pointer(s))
+	if !t.render(r, img, ts, s) {
+		return nil, nodeNotFound(id)
+	}
+	return img, nil
+}
+
+// nodeBBox returns the bounding box of the node with the id. The tree must be
+// read locked.
+func (t *Tree) nodeBBox(id string, stroke bool) (Rect, error) {
+	s := C.CString(id)
+	defer C.free(unsafe.Pointer(s))
+	var bbox C.resvg_rect
+	var ok C.bool
+	if stroke {
+		ok = C.resvg_get_node_stroke_bbox(t.tree, s, &bbox)
+	} else {
+		ok = C.resvg_get_node_bbox(t.tree, s, &bbox)
+	}
+	if !ok {
+		return Rect{}, nodeNotFound(id)
+	}
+	return newRect(bbox), nil
+}
+
+// nodeNotFound wraps [ErrNodeNotFound] with the node id.
+func nodeNotFound(id string) error {
+	return fmt.Errorf("%w: %q", ErrNodeNotFound, id)
+}
@@ -0,0 +1,72 @@
+package resvg
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNode(t *testing.T) {
+	tree, err := Parse(rectSVG)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer tree.Close()
+	if !tree.NodeExists("box") {
+		t.Errorf("expected node box to exist")
+	}
+	if tree.NodeExists("missing") {
+		t.Errorf("expected node missing to not exist")
+	}
+	ts, err := tree.NodeTransform("box")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if exp := (Transform{A: 1, D: 1}); ts != exp {
+		t.Errorf("expected %v, got: %v", exp, ts)
+	}
+	bbox, err := tree.NodeBBox("box")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if exp := (Rect{50, 20, 150, 150}); bbox != exp {
+		t.Errorf("expected %v, got: %v", exp, bbox)
+	}
+	strokeBBox, err := tree.NodeStrokeBBox("box")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if exp := (Rect{47.5, 17.5, 155, 155}); strokeBBox != exp {
+		t.Errorf("expected %v, got: %v", exp, strokeBBox)
+	}
+	if _, err := tree.NodeBBox("missing"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("expected ErrNodeNotFound, got: %v", err)
+	}
+	if _, err := tree.NodeTransform("missing"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("expected ErrNodeNotFound, got: %v", err)
+	}
+}
+
+func TestRenderNode(t *testing.T) {
+	tests := []struct {
+		opts []Option
+		expw int
+		exph int
+	}{
+		{nil, 150, 150},
+		{[]Option{WithNodeStrokeBBox(true)}, 155, 155},
+		{[]Option{WithScaleMode(ScaleBestFit), WithWidth(300)}, 300, 300},
+		{[]Option{WithWidth(75), WithHeight(30)}, 75, 30},
+	}
+	for i, test := range tests {
+		img, err := RenderNode(rectSVG, "box", test.opts...)
+		if err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		if size := img.Bounds().Size(); size.X != test.expw || size.Y != test.exph {
+			t.Errorf("test %d expected %dx%d, got: %dx%d", i, test.expw, test.exph, size.X, size.Y)
+		}
+	}
+	if _, err := RenderNode(rectSVG, "missing"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("expected ErrNodeNotFound, got: %v", err)
+	}
+}
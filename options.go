@@ -0,0 +1,178 @@
+package resvg
+
+/*
+#include <stdlib.h>
+#include <errno.h>
+
+#include "resvg.h"
+
+resvg_render_tree* parse(const char* data, uintptr_t n, const char* dir, resvg_options* opts) {
+	// parse, resolving relative paths against dir
+	resvg_render_tree* tree;
+	if (dir != 0) {
+		resvg_options_set_resources_dir(opts, dir);
+	}
+	errno = resvg_parse_tree_from_data(data, n, opts, &tree);
+	if (dir != 0) {
+		resvg_options_set_resources_dir(opts, 0);
+	}
+	if (errno != 0) {
+		return 0;
+	}
+	return tree;
+}
+*/
+import "C"
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// options are resvg options shared by renderers with the same settings, as
+// loading system fonts is expensive.
+type options struct {
+	key  string
+	opts *C.resvg_options
+	refs int
+	once sync.Once
+	// rw is exclusively locked when the options are temporarily modified
+	rw sync.RWMutex
+}
+
+// parse parses the svg data, resolving relative paths against dir when not
+// empty. As dir is temporarily set on the options, options parsing with a dir
+// must not be shared.
+func (o *options) parse(data []byte, dir string) (*C.resvg_render_tree, error) {
+	var s *C.char
+	if dir != "" {
+		s = C.CString(dir)
+		defer C.free(unsafe.Pointer(s))
+		o.rw.Lock()
+		defer o.rw.Unlock()
+	} else {
+		o.rw.RLock()
+		defer o.rw.RUnlock()
+	}
+	tree, err := C.parse((*C.char)(unsafe.Pointer(unsafe.SliceData(data))), C.uintptr_t(len(data)), s, o.opts)
+	if err != nil {
+		return nil, newErrNo(err)
+	}
+	return tree, nil
+}
+
+// maxIdleOptions is the maximum number of unused options kept in the options
+// cache.
+const maxIdleOptions = 4
+
+// optionsCache is the shared options cache.
+var optionsCache = struct {
+	m map[string]*options
+	// idle are the unused options, least recently used first
+	idle []*options
+	sync.Mutex
+}{
+	m: make(map[string]*options),
+}
+
+// acquireOptions returns options for the renderer's settings, from the cache
+// when enabled.
+func acquireOptions(r *Resvg) *options {
+	if r.noOptionsCache {
+		return &options{
+			opts: r.createOpts(),
+			refs: 1,
+		}
+	}
+	key := r.optionsKey()
+	optionsCache.Lock()
+	o, ok := optionsCache.m[key]
+	switch {
+	case !ok:
+		o = &options{key: key}
+		optionsCache.m[key] = o
+	case o.refs == 0:
+		// no longer idle
+		i := slices.Index(optionsCache.idle, o)
+		optionsCache.idle = slices.Delete(optionsCache.idle, i, i+1)
+	}
+	o.refs++
+	optionsCache.Unlock()
+	// load outside of the cache lock, so options with different settings
+	// can be loaded concurrently
+	o.once.Do(func() {
+		o.opts = r.createOpts()
+	})
+	return o
+}
+
+// release releases a reference to the options. Unused cached options are
+// kept until evicted by more recently used options, while other unused
+// options are destroyed.
+func (o *options) release() {
+	optionsCache.Lock()
+	o.refs--
+	var evict []*options
+	switch {
+	case o.refs != 0:
+	case o.key != "" && optionsCache.m[o.key] == o:
+		optionsCache.idle = append(optionsCache.idle, o)
+		if n := len(optionsCache.idle) - maxIdleOptions; n > 0 {
+			evict = slices.Clone(optionsCache.idle[:n])
+			optionsCache.idle = slices.Delete(optionsCache.idle, 0, n)
+		}
+	default:
+		evict = []*options{o}
+	}
+	for _, e := range evict {
+		if optionsCache.m[e.key] == e {
+			delete(optionsCache.m, e.key)
+		}
+	}
+	optionsCache.Unlock()
+	for _, e := range evict {
+		e.destroy()
+	}
+}
+
+// destroy destroys the options.
+func (o *options) destroy() {
+	o.rw.Lock()
+	defer o.rw.Unlock()
+	if o.opts != nil {
+		C.resvg_options_destroy(o.opts)
+	}
+	o.opts = nil
+}
+
+// ClearOptionsCache clears the shared options cache. Options still in use by
+// a renderer are destroyed when the last renderer using them is released.
+func ClearOptionsCache() {
+	optionsCache.Lock()
+	idle := optionsCache.idle
+	optionsCache.m, optionsCache.idle = make(map[string]*options), nil
+	optionsCache.Unlock()
+	for _, o := range idle {
+		o.destroy()
+	}
+}
+
+// optionsKey returns the options cache key for the renderer's settings.
+func (r *Resvg) optionsKey() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%t|%q|%v|%q|%v|", r.loadSystemFonts, r.resourcesDir, r.dp, r.fontFamily, r.fontSize)
+	fmt.Fprintf(&sb, "%q|%q|%q|%q|%q|", r.serifFamily, r.sansSerifFamily, r.cursiveFamily, r.fantasyFamily, r.monospaceFamily)
+	fmt.Fprintf(&sb, "%q|%d|%d|%d|", r.languages, r.shapeRendering, r.textRendering, r.imageRendering)
+	for _, font := range r.fonts {
+		h := sha256.Sum256(font)
+		sb.WriteString(hex.EncodeToString(h[:]))
+		sb.WriteByte(',')
+	}
+	fmt.Fprintf(&sb, "|%q", r.fontFiles)
+	return sb.String()
+}
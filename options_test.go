@@ -0,0 +1,178 @@
+package resvg
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOptionsCache(t *testing.T) {
+	ClearOptionsCache()
+	a, b := New(WithFontSize(14)), New(WithFontSize(14))
+	c := New(WithFontSize(14), WithFonts([]byte("font")))
+	d := New(WithFontSize(14), WithOptionsCache(false))
+	for _, r := range []*Resvg{a, b, c, d} {
+		if _, err := r.Render(rectSVG); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+	switch {
+	case a.opts != b.opts:
+		t.Errorf("expected a and b to share options")
+	case a.opts == c.opts:
+		t.Errorf("expected a and c to not share options")
+	case a.opts == d.opts:
+		t.Errorf("expected a and d to not share options")
+	}
+	if refs := a.opts.refs; refs != 2 {
+		t.Errorf("expected 2 refs, got: %d", refs)
+	}
+	o := a.opts
+	ClearOptionsCache()
+	a.finalize()
+	if o.opts == nil {
+		t.Fatalf("expected options to not be destroyed while in use")
+	}
+	b.finalize()
+	if o.opts != nil {
+		t.Errorf("expected options to be destroyed")
+	}
+	e := New(WithFontSize(14))
+	if _, err := e.Render(rectSVG); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if e.opts == o {
+		t.Errorf("expected new options after clearing the cache")
+	}
+}
+
+func TestOptionsCacheEvict(t *testing.T) {
+	ClearOptionsCache()
+	var opts []*options
+	for i := range maxIdleOptions + 2 {
+		r := New(WithFontSize(float32(20 + i)))
+		if _, err := r.Render(rectSVG); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		opts = append(opts, r.opts)
+		if err := r.Close(); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+	optionsCache.Lock()
+	n := len(optionsCache.m)
+	optionsCache.Unlock()
+	if n != maxIdleOptions {
+		t.Errorf("expected %d cached options, got: %d", maxIdleOptions, n)
+	}
+	for i, o := range opts {
+		if evicted := i < 2; evicted != (o.opts == nil) {
+			t.Errorf("options %d expected evicted %t", i, evicted)
+		}
+	}
+	// reusing idle options
+	r := New(WithFontSize(float32(20 + maxIdleOptions + 1)))
+	defer r.Close()
+	if _, err := r.Render(rectSVG); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if r.opts != opts[len(opts)-1] {
+		t.Errorf("expected idle options to be reused")
+	}
+	ClearOptionsCache()
+	if opts[2].opts != nil {
+		t.Errorf("expected idle options to be destroyed")
+	}
+	if r.opts.opts == nil {
+		t.Errorf("expected options in use to not be destroyed")
+	}
+}
+
+func TestOptionsCacheParseFile(t *testing.T) {
+	ClearOptionsCache()
+	a, b := New(WithFontSize(15)), New(WithFontSize(15))
+	defer a.Close()
+	defer b.Close()
+	var wg sync.WaitGroup
+	for i := range 8 {
+		r := a
+		if i%2 == 1 {
+			r = b
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 10 {
+				tree, err := r.ParseFile("testdata/rect.svg")
+				if err != nil {
+					t.Errorf("expected no error, got: %v", err)
+					return
+				}
+				tree.Close()
+				if tree, err = r.Parse(rectSVG); err != nil {
+					t.Errorf("expected no error, got: %v", err)
+					return
+				}
+				tree.Close()
+			}
+		}()
+	}
+	wg.Wait()
+	dir, err := filepath.Abs("testdata")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	optionsCache.Lock()
+	o := optionsCache.m[New(WithFontSize(15), WithResourcesDir(dir)).optionsKey()]
+	optionsCache.Unlock()
+	switch {
+	case o == nil:
+		t.Fatalf("expected options for the file's directory")
+	case o == a.opts:
+		t.Fatalf("expected directory options to not be shared with parse options")
+	}
+	// file parses only read lock the shared options
+	a.opts.rw.RLock()
+	defer a.opts.rw.RUnlock()
+	o.rw.RLock()
+	defer o.rw.RUnlock()
+	done := make(chan error, 1)
+	go func() {
+		tree, err := b.ParseFile("testdata/rect.svg")
+		if err == nil {
+			tree.Close()
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected no error, got: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("expected file parse to not exclusively lock shared options")
+	}
+}
+
+func TestOptionsRelease(t *testing.T) {
+	ClearOptionsCache()
+	opts := []Option{WithFontSize(33)}
+	if _, err := Render(rectSVG, opts...); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	tree, err := Parse(rectSVG, opts...)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer tree.Close()
+	optionsCache.Lock()
+	defer optionsCache.Unlock()
+	o := optionsCache.m[New(opts...).optionsKey()]
+	switch {
+	case o == nil:
+		t.Fatalf("expected cached options")
+	case o.refs != 0:
+		t.Errorf("expected options to be released, got %d refs", o.refs)
+	}
+}
@@ -0,0 +1,197 @@
+package resvg
+
+import (
+	"image"
+)
+
+// PixelFormat is a pixel channel byte order.
+type PixelFormat uint8
+
+// Pixel formats.
+//
+// Note that ARGB32 (as used by Cairo and Qt) is a native endian 32 bit
+// value, which on little endian systems is [PixelBGRA].
+const (
+	PixelRGBA PixelFormat = iota
+	PixelBGRA
+	PixelARGB
+	PixelABGR
+)
+
+// Layout is a pixel buffer layout.
+type Layout struct {
+	// Format is the channel byte order.
+	Format PixelFormat
+	// Straight toggles straight (non-premultiplied) alpha. Rendered pixels
+	// are premultiplied.
+	Straight bool
+	// FlipY toggles storing rows bottom-up.
+	FlipY bool
+	// Stride is the number of bytes per row. When 0, rows are tightly packed.
+	Stride int
+}
+
+// Buffer is a pixel buffer.
+type Buffer struct {
+	Pix    []byte
+	Width  int
+	Height int
+	Stride int
+	Layout Layout
+}
+
+// RenderBuffer renders the tree into a pixel buffer with the layout.
+func (t *Tree) RenderBuffer(layout Layout, opts ...Option) (*Buffer, error) {
+	img, err := t.Render(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return convertRGBA(img, layout, true)
+}
+
+// RenderNRGBA renders the tree as a NRGBA (straight alpha) image.
+func (t *Tree) RenderNRGBA(opts ...Option) (*image.NRGBA, error) {
+	img, err := t.Render(opts...)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := convertRGBA(img, Layout{Straight: true}, true)
+	if err != nil {
+		return nil, err
+	}
+	return buf.NRGBA(), nil
+}
+
+// RenderBuffer renders svg data into a pixel buffer with the layout.
+func (r *Resvg) RenderBuffer(data []byte, layout Layout) (*Buffer, error) {
+	tree, err := r.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+	return tree.RenderBuffer(layout)
+}
+
+// RenderNRGBA renders svg data as a NRGBA (straight alpha) image.
+func (r *Resvg) RenderNRGBA(data []byte) (*image.NRGBA, error) {
+	tree, err := r.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+	return tree.RenderNRGBA()
+}
+
+// ConvertRGBA converts the premultiplied RGBA image to a pixel buffer with
+// the layout.
+func ConvertRGBA(img *image.RGBA, layout Layout) (*Buffer, error) {
+	return convertRGBA(img, layout, false)
+}
+
+// ToNRGBA converts the premultiplied RGBA image to a NRGBA (straight alpha)
+// image.
+func ToNRGBA(img *image.RGBA) *image.NRGBA {
+	buf, _ := convertRGBA(img, Layout{Straight: true}, false)
+	return buf.NRGBA()
+}
+
+// NRGBA returns the buffer as a NRGBA image, sharing the buffer's pixels.
+// Returns nil when the buffer's layout is not straight alpha, top-down RGBA.
+func (buf *Buffer) NRGBA() *image.NRGBA {
+	if buf.Layout.Format != PixelRGBA || !buf.Layout.Straight || buf.Layout.FlipY {
+		return nil
+	}
+	return &image.NRGBA{
+		Pix:    buf.Pix,
+		Stride: buf.Stride,
+		Rect:   image.Rect(0, 0, buf.Width, buf.Height),
+	}
+}
+
+// convertRGBA converts the image to a pixel buffer with the layout. When
+// inPlace is true, the image's pixels are converted in place when possible.
+func convertRGBA(img *image.RGBA, layout Layout, inPlace bool) (*Buffer, error) {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	stride := layout.Stride
+	switch {
+	case stride == 0:
+		stride = 4 * width
+	case stride < 4*width:
+		return nil, ErrInvalidStride
+	}
+	conv := rowConverter(layout)
+	buf := &Buffer{
+		Width:  width,
+		Height: height,
+		Stride: stride,
+		Layout: layout,
+	}
+	if inPlace && stride == img.Stride && img.Rect.Min == (image.Point{}) {
+		buf.Pix = img.Pix
+		for y := range height {
+			row := buf.Pix[y*stride : y*stride+4*width]
+			conv(row, row)
+		}
+		if layout.FlipY {
+			flipRows(buf.Pix, stride, 4*width, height)
+		}
+		return buf, nil
+	}
+	if height == 0 {
+		buf.Pix = []byte{}
+		return buf, nil
+	}
+	buf.Pix = make([]byte, stride*(height-1)+4*width)
+	for y := range height {
+		dy := y
+		if layout.FlipY {
+			dy = height - 1 - y
+		}
+		src := img.Pix[img.PixOffset(img.Rect.Min.X, img.Rect.Min.Y+y):][:4*width]
+		conv(buf.Pix[dy*stride:dy*stride+4*width], src)
+	}
+	return buf, nil
+}
+
+// flipRows reverses the order of n rows in pix in place.
+func flipRows(pix []byte, stride, n, height int) {
+	tmp := make([]byte, n)
+	for i, j := 0, height-1; i < j; i, j = i+1, j-1 {
+		a, b := pix[i*stride:i*stride+n], pix[j*stride:j*stride+n]
+		copy(tmp, a)
+		copy(a, b)
+		copy(b, tmp)
+	}
+}
+
+// rowConverter returns a func that converts a row of premultiplied RGBA
+// pixels in src to the layout in dst. dst and src may be the same slice.
+func rowConverter(layout Layout) func(dst, src []byte) {
+	// channel offsets for r, g, b, a
+	var o [4]int
+	switch layout.Format {
+	case PixelBGRA:
+		o = [4]int{2, 1, 0, 3}
+	case PixelARGB:
+		o = [4]int{1, 2, 3, 0}
+	case PixelABGR:
+		o = [4]int{3, 2, 1, 0}
+	default:
+		o = [4]int{0, 1, 2, 3}
+	}
+	straight := layout.Straight
+	return func(dst, src []byte) {
+		for i := 0; i+3 < len(src); i += 4 {
+			r, g, b, a := src[i], src[i+1], src[i+2], src[i+3]
+			if straight && a != 0 && a != 0xff {
+				r, g, b = unpremultiply(r, a), unpremultiply(g, a), unpremultiply(b, a)
+			}
+			dst[i+o[0]], dst[i+o[1]], dst[i+o[2]], dst[i+o[3]] = r, g, b, a
+		}
+	}
+}
+
+// unpremultiply converts a premultiplied color channel to straight alpha.
+func unpremultiply(c, a uint8) uint8 {
+	return uint8(min((uint32(c)*0xff+uint32(a)/2)/uint32(a), 0xff))
+}
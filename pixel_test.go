@@ -0,0 +1,92 @@
+package resvg
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"testing"
+)
+
+func TestConvertRGBA(t *testing.T) {
+	img := &image.RGBA{
+		Pix: []byte{
+			255, 0, 0, 255, 64, 32, 0, 128,
+			0, 0, 0, 0, 10, 20, 30, 40,
+		},
+		Stride: 8,
+		Rect:   image.Rect(0, 0, 2, 2),
+	}
+	tests := []struct {
+		layout Layout
+		exp    []byte
+	}{
+		{Layout{}, []byte{
+			255, 0, 0, 255, 64, 32, 0, 128,
+			0, 0, 0, 0, 10, 20, 30, 40,
+		}},
+		{Layout{Straight: true}, []byte{
+			255, 0, 0, 255, 128, 64, 0, 128,
+			0, 0, 0, 0, 64, 128, 191, 40,
+		}},
+		{Layout{Format: PixelBGRA}, []byte{
+			0, 0, 255, 255, 0, 32, 64, 128,
+			0, 0, 0, 0, 30, 20, 10, 40,
+		}},
+		{Layout{Format: PixelARGB, FlipY: true}, []byte{
+			0, 0, 0, 0, 40, 10, 20, 30,
+			255, 255, 0, 0, 128, 64, 32, 0,
+		}},
+		{Layout{Format: PixelABGR, Stride: 10}, []byte{
+			255, 0, 0, 255, 128, 0, 32, 64, 0, 0,
+			0, 0, 0, 0, 40, 30, 20, 10,
+		}},
+	}
+	for i, test := range tests {
+		buf, err := ConvertRGBA(img, test.layout)
+		if err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		if !bytes.Equal(buf.Pix, test.exp) {
+			t.Errorf("test %d expected %v, got: %v", i, test.exp, buf.Pix)
+		}
+	}
+	if _, err := ConvertRGBA(img, Layout{Stride: 4}); !errors.Is(err, ErrInvalidStride) {
+		t.Errorf("expected ErrInvalidStride, got: %v", err)
+	}
+	for i, layout := range []Layout{{}, {Stride: 32}, {Stride: 32, FlipY: true}} {
+		buf, err := ConvertRGBA(image.NewRGBA(image.Rect(0, 0, 5, 0)), layout)
+		if err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		if buf.Width != 5 || buf.Height != 0 || len(buf.Pix) != 0 {
+			t.Errorf("test %d expected empty 5x0 buffer, got: %dx%d (%d bytes)", i, buf.Width, buf.Height, len(buf.Pix))
+		}
+	}
+	nrgba := ToNRGBA(img)
+	if c := nrgba.NRGBAAt(1, 0); c.R != 128 || c.G != 64 || c.B != 0 || c.A != 128 {
+		t.Errorf("expected {128 64 0 128}, got: %v", c)
+	}
+	if img.Pix[4] != 64 {
+		t.Errorf("expected source image to be unmodified")
+	}
+}
+
+func TestRenderBuffer(t *testing.T) {
+	buf, err := New(WithWidth(4), WithHeight(2)).RenderBuffer(redSVG, Layout{Format: PixelBGRA, FlipY: true, Stride: 20})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if buf.Width != 4 || buf.Height != 2 || buf.Stride != 20 || len(buf.Pix) != 36 {
+		t.Errorf("expected 4x2 with stride 20 and 36 bytes, got: %dx%d with stride %d and %d bytes", buf.Width, buf.Height, buf.Stride, len(buf.Pix))
+	}
+	if p := buf.Pix[20:24]; !bytes.Equal(p, []byte{0, 0, 255, 255}) {
+		t.Errorf("expected blue-green-red-alpha red, got: %v", p)
+	}
+	nrgba, err := New().RenderNRGBA(redSVG)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if size := nrgba.Bounds().Size(); size.X != 10 || size.Y != 10 {
+		t.Errorf("expected 10x10, got: %dx%d", size.X, size.Y)
+	}
+}
@@ -0,0 +1,143 @@
+package resvg
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+	"math"
+	"strings"
+)
+
+// Metadata is svg metadata written by encoders supporting it.
+type Metadata struct {
+	// Title is the root svg element's title.
+	Title string
+	// Description is the root svg element's desc.
+	Description string
+	// SourceHash is the hex encoded sha256 hash of the svg data.
+	SourceHash string
+}
+
+// NewMetadata extracts the metadata from svg or svgz data.
+func NewMetadata(data []byte) Metadata {
+	sum := sha256.Sum256(data)
+	meta := Metadata{
+		SourceHash: hex.EncodeToString(sum[:]),
+	}
+	var r io.Reader = bytes.NewReader(data)
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		z, err := gzip.NewReader(r)
+		if err != nil {
+			return meta
+		}
+		defer z.Close()
+		r = z
+	}
+	// find the first title and desc children of the root element
+	d := xml.NewDecoder(r)
+	d.Strict = false
+	for depth := 0; meta.Title == "" || meta.Description == ""; {
+		tok, err := d.Token()
+		if err != nil {
+			break
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if depth == 1 && (t.Name.Local == "title" || t.Name.Local == "desc") {
+				var v struct {
+					Text string `xml:",chardata"`
+				}
+				if err := d.DecodeElement(&v, &t); err != nil {
+					return meta
+				}
+				text := strings.Join(strings.Fields(v.Text), " ")
+				switch {
+				case t.Name.Local == "title" && meta.Title == "":
+					meta.Title = text
+				case t.Name.Local == "desc" && meta.Description == "":
+					meta.Description = text
+				}
+				continue
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+	}
+	return meta
+}
+
+// EncodePNG encodes the image as a png, with a pHYs chunk for the output DPI
+// (when set), a sRGB chunk, and text chunks for the metadata and the resvg
+// version.
+func (r *Resvg) EncodePNG(w io.Writer, img *image.RGBA, meta Metadata) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	// chunks following IHDR, which is always first
+	var chunks bytes.Buffer
+	writeChunk(&chunks, "sRGB", []byte{0}) // perceptual
+	if r.outputDPI != 0.0 {
+		ppm := uint32(math.Round(float64(r.outputDPI) / 0.0254))
+		b := make([]byte, 9)
+		binary.BigEndian.PutUint32(b[0:], ppm)
+		binary.BigEndian.PutUint32(b[4:], ppm)
+		b[8] = 1 // meter
+		writeChunk(&chunks, "pHYs", b)
+	}
+	for _, text := range [][2]string{
+		{"Title", meta.Title},
+		{"Description", meta.Description},
+		{"Source SHA256", meta.SourceHash},
+		{"Software", "resvg " + Version()},
+	} {
+		if text[1] != "" {
+			writeText(&chunks, text[0], text[1])
+		}
+	}
+	b := buf.Bytes()
+	// signature (8) + IHDR length, type, data (13), crc
+	const ihdrEnd = 8 + 4 + 4 + 13 + 4
+	for _, p := range [][]byte{b[:ihdrEnd], chunks.Bytes(), b[ihdrEnd:]} {
+		if _, err := w.Write(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeText writes a tEXt chunk for ascii text, or a iTXt chunk otherwise.
+func writeText(buf *bytes.Buffer, keyword, text string) {
+	ascii := true
+	for i := 0; i < len(text) && ascii; i++ {
+		ascii = text[i] < 0x80
+	}
+	if ascii {
+		writeChunk(buf, "tEXt", []byte(keyword+"\x00"+text))
+		return
+	}
+	// no compression, empty language tag and translated keyword
+	writeChunk(buf, "iTXt", []byte(keyword+"\x00\x00\x00\x00\x00"+text))
+}
+
+// writeChunk writes a png chunk.
+func writeChunk(buf *bytes.Buffer, typ string, data []byte) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], uint32(len(data)))
+	buf.Write(b[:])
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(typ))
+	crc.Write(data)
+	buf.WriteString(typ)
+	buf.Write(data)
+	binary.BigEndian.PutUint32(b[:], crc.Sum32())
+	buf.Write(b[:])
+}
@@ -0,0 +1,115 @@
+package resvg
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"hash/crc32"
+	"image/png"
+	"testing"
+)
+
+var titleSVG = []byte(`<?xml version="1.0"?>
+<svg width="10" height="10" xmlns="http://www.w3.org/2000/svg">
+  <g><title>nested</title></g>
+  <title>
+    Red   Square
+  </title>
+  <desc>A red square — très rouge</desc>
+  <rect width="10" height="10" fill="#f00"/>
+</svg>`)
+
+func TestNewMetadata(t *testing.T) {
+	sum := sha256.Sum256(titleSVG)
+	exp := Metadata{
+		Title:       "Red Square",
+		Description: "A red square — très rouge",
+		SourceHash:  hex.EncodeToString(sum[:]),
+	}
+	if meta := NewMetadata(titleSVG); meta != exp {
+		t.Errorf("expected %+v, got: %+v", exp, meta)
+	}
+	var buf bytes.Buffer
+	z := gzip.NewWriter(&buf)
+	_, _ = z.Write(titleSVG)
+	_ = z.Close()
+	if meta := NewMetadata(buf.Bytes()); meta.Title != exp.Title || meta.Description != exp.Description {
+		t.Errorf("expected svgz title and description, got: %+v", meta)
+	}
+}
+
+func TestEncodePNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderEncode(&buf, titleSVG, FormatPNG, WithOutputDPI(300)); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	chunks := pngChunks(t, buf.Bytes())
+	if len(chunks) < 3 || chunks[0].typ != "IHDR" || chunks[1].typ != "sRGB" || chunks[2].typ != "pHYs" {
+		t.Fatalf("expected IHDR, sRGB, pHYs chunks, got: %v", chunks)
+	}
+	if ppm := binary.BigEndian.Uint32(chunks[2].data); ppm != 11811 || chunks[2].data[8] != 1 {
+		t.Errorf("expected 11811 pixels per meter, got: %d", ppm)
+	}
+	text := make(map[string]string)
+	for _, c := range chunks {
+		switch c.typ {
+		case "tEXt":
+			k, v, _ := bytes.Cut(c.data, []byte{0})
+			text[string(k)] = string(v)
+		case "iTXt":
+			k, v, _ := bytes.Cut(c.data, []byte{0})
+			text[string(k)] = string(v[4:])
+		}
+	}
+	meta := NewMetadata(titleSVG)
+	for k, v := range map[string]string{
+		"Title":         meta.Title,
+		"Description":   meta.Description,
+		"Source SHA256": meta.SourceHash,
+		"Software":      "resvg " + Version(),
+	} {
+		if text[k] != v {
+			t.Errorf("expected %s %q, got: %q", k, v, text[k])
+		}
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if size := img.Bounds().Size(); size.X != 10 || size.Y != 10 {
+		t.Errorf("expected 10x10, got: %dx%d", size.X, size.Y)
+	}
+}
+
+type pngChunk struct {
+	typ  string
+	data []byte
+}
+
+func pngChunks(t *testing.T, b []byte) []pngChunk {
+	t.Helper()
+	var chunks []pngChunk
+	for b = b[8:]; len(b) >= 12; {
+		n := binary.BigEndian.Uint32(b)
+		c := pngChunk{string(b[4:8]), b[8 : 8+n]}
+		if crc := crc32.ChecksumIEEE(b[4 : 8+n]); crc != binary.BigEndian.Uint32(b[8+n:]) {
+			t.Fatalf("invalid crc for %s chunk", c.typ)
+		}
+		chunks, b = append(chunks, c), b[12+n:]
+	}
+	return chunks
+}
+
+func TestEncodePNGNoOutputDPI(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderEncode(&buf, titleSVG, FormatPNG, WithDPI(300)); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	for _, c := range pngChunks(t, buf.Bytes()) {
+		if c.typ == "pHYs" {
+			t.Fatalf("expected no pHYs chunk, got: %v", c.data)
+		}
+	}
+}
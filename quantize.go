@@ -0,0 +1,174 @@
+package resvg
+
+import (
+	"image"
+	"image/color"
+	"sort"
+)
+
+// Quantize generates a palette of up to n colors for the image, using median
+// cut over a 15 bit color histogram. Alpha is ignored, so transparent images
+// should be flattened first (see [Flatten]).
+func Quantize(img image.Image, n int) color.Palette {
+	if n <= 0 {
+		return nil
+	}
+	// histogram
+	var hist [1 << 15]colorBin
+	b := img.Bounds()
+	if rgba, ok := img.(*image.RGBA); ok {
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			row := rgba.Pix[rgba.PixOffset(b.Min.X, y):][:4*b.Dx()]
+			for i := 0; i < len(row); i += 4 {
+				hist[binIndex(row[i], row[i+1], row[i+2])].add(row[i], row[i+1], row[i+2])
+			}
+		}
+	} else {
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+				hist[binIndex(c.R, c.G, c.B)].add(c.R, c.G, c.B)
+			}
+		}
+	}
+	return medianCut(&hist, n)
+}
+
+// quantizeMasked generates a palette of up to n colors for the flattened
+// image, ignoring pixels that are mostly transparent in the mask.
+func quantizeMasked(flat, mask *image.RGBA, n int) color.Palette {
+	if n <= 0 {
+		return nil
+	}
+	var hist [1 << 15]colorBin
+	width, height := flat.Rect.Dx(), flat.Rect.Dy()
+	for y := range height {
+		row := flat.Pix[flat.PixOffset(flat.Rect.Min.X, flat.Rect.Min.Y+y):][:4*width]
+		alpha := mask.Pix[mask.PixOffset(mask.Rect.Min.X, mask.Rect.Min.Y+y):][:4*width]
+		for i := 0; i < len(row); i += 4 {
+			if alpha[i+3] >= 0x80 {
+				hist[binIndex(row[i], row[i+1], row[i+2])].add(row[i], row[i+1], row[i+2])
+			}
+		}
+	}
+	return medianCut(&hist, n)
+}
+
+// medianCut generates a palette of up to n colors from the histogram.
+func medianCut(hist *[1 << 15]colorBin, n int) color.Palette {
+	var bins []colorBin
+	for i := range hist {
+		if hist[i].n != 0 {
+			hist[i].idx = i
+			bins = append(bins, hist[i])
+		}
+	}
+	if len(bins) == 0 {
+		return nil
+	}
+	// split the box with the most pixels until there are n boxes
+	boxes := []colorBox{newColorBox(bins)}
+	for len(boxes) < n {
+		i := -1
+		for j, box := range boxes {
+			if len(box.bins) > 1 && (i == -1 || box.n > boxes[i].n) {
+				i = j
+			}
+		}
+		if i == -1 {
+			break
+		}
+		a, b := boxes[i].split()
+		boxes[i] = a
+		boxes = append(boxes, b)
+	}
+	palette := make(color.Palette, len(boxes))
+	for i, box := range boxes {
+		palette[i] = box.color()
+	}
+	return palette
+}
+
+// colorBin is a color histogram bin.
+type colorBin struct {
+	idx     int
+	n       int
+	r, g, b int
+}
+
+// add adds the color to the bin.
+func (bin *colorBin) add(r, g, b uint8) {
+	bin.n++
+	bin.r += int(r)
+	bin.g += int(g)
+	bin.b += int(b)
+}
+
+// binIndex returns the 15 bit histogram index for the color.
+func binIndex(r, g, b uint8) int {
+	return int(r>>3)<<10 | int(g>>3)<<5 | int(b>>3)
+}
+
+// colorBox is a median cut box of histogram bins.
+type colorBox struct {
+	bins []colorBin
+	n    int
+}
+
+// newColorBox creates a new color box.
+func newColorBox(bins []colorBin) colorBox {
+	box := colorBox{bins: bins}
+	for _, bin := range bins {
+		box.n += bin.n
+	}
+	return box
+}
+
+// split splits the box at the weighted median of its widest channel.
+func (box colorBox) split() (colorBox, colorBox) {
+	// determine widest channel
+	lo, hi := [3]int{31, 31, 31}, [3]int{}
+	for _, bin := range box.bins {
+		for c, v := range [3]int{bin.idx >> 10, bin.idx >> 5 & 31, bin.idx & 31} {
+			lo[c], hi[c] = min(lo[c], v), max(hi[c], v)
+		}
+	}
+	c := 0
+	for i := 1; i < 3; i++ {
+		if hi[i]-lo[i] > hi[c]-lo[c] {
+			c = i
+		}
+	}
+	shift := 10 - 5*c
+	sort.Slice(box.bins, func(i, j int) bool {
+		a, b := box.bins[i].idx>>shift&31, box.bins[j].idx>>shift&31
+		if a != b {
+			return a < b
+		}
+		return box.bins[i].idx < box.bins[j].idx
+	})
+	// weighted median, keeping at least one bin in each box
+	i, n := 0, 0
+	for ; i < len(box.bins)-1; i++ {
+		if n += box.bins[i].n; 2*n >= box.n {
+			i++
+			break
+		}
+	}
+	i = max(i, 1)
+	return newColorBox(box.bins[:i]), newColorBox(box.bins[i:])
+}
+
+// color returns the average color of the box.
+func (box colorBox) color() color.Color {
+	var r, g, b int
+	for _, bin := range box.bins {
+		r, g, b = r+bin.r, g+bin.g, b+bin.b
+	}
+	return color.RGBA{
+		uint8((r + box.n/2) / box.n),
+		uint8((g + box.n/2) / box.n),
+		uint8((b + box.n/2) / box.n),
+		0xff,
+	}
+}
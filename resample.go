@@ -0,0 +1,150 @@
+package resvg
+
+import (
+	"image"
+	"math"
+)
+
+// Filter is a downsampling filter.
+type Filter uint8
+
+// Downsampling filters.
+const (
+	// FilterBox averages each block of source pixels.
+	FilterBox Filter = iota
+	// FilterBilinear uses a triangle filter.
+	FilterBilinear
+	// FilterLanczos uses a 3 lobe Lanczos filter.
+	FilterLanczos
+)
+
+// support returns the filter's support radius, in output pixels.
+func (filter Filter) support() float64 {
+	switch filter {
+	case FilterBilinear:
+		return 1.0
+	case FilterLanczos:
+		return 3.0
+	}
+	return 0.5
+}
+
+// kernel evaluates the filter's kernel at x, in output pixels.
+func (filter Filter) kernel(x float64) float64 {
+	x = math.Abs(x)
+	switch filter {
+	case FilterBilinear:
+		return max(1.0-x, 0.0)
+	case FilterLanczos:
+		switch {
+		case x == 0.0:
+			return 1.0
+		case x >= 3.0:
+			return 0.0
+		}
+		px := math.Pi * x
+		return 3.0 * math.Sin(px) * math.Sin(px/3.0) / (px * px)
+	}
+	if x < 0.5 {
+		return 1.0
+	}
+	return 0.0
+}
+
+// downsample downsamples the premultiplied src image, which is n times the
+// size of dst, into dst using the filter.
+func downsample(dst, src *image.RGBA, n int, filter Filter) {
+	width, height := dst.Rect.Dx(), dst.Rect.Dy()
+	if filter == FilterBox {
+		downsampleBox(dst, src, n)
+		return
+	}
+	// horizontal pass, into a buffer of width x src height
+	xw := filterWeights(width, n, src.Rect.Dx(), filter)
+	tmp := make([]float32, 4*width*src.Rect.Dy())
+	for y := range src.Rect.Dy() {
+		row := src.Pix[y*src.Stride:]
+		out := tmp[4*width*y:]
+		for x, w := range xw {
+			var r, g, b, a float32
+			for i, k := range w.k {
+				p := row[4*(w.start+i):]
+				r, g, b, a = r+k*float32(p[0]), g+k*float32(p[1]), b+k*float32(p[2]), a+k*float32(p[3])
+			}
+			out[4*x], out[4*x+1], out[4*x+2], out[4*x+3] = r, g, b, a
+		}
+	}
+	// vertical pass
+	yw := filterWeights(height, n, src.Rect.Dy(), filter)
+	for y, w := range yw {
+		out := dst.Pix[y*dst.Stride:]
+		for x := range width {
+			var r, g, b, a float32
+			for i, k := range w.k {
+				p := tmp[4*(width*(w.start+i)+x):]
+				r, g, b, a = r+k*p[0], g+k*p[1], b+k*p[2], a+k*p[3]
+			}
+			// keep premultiplied colors valid, as lanczos can overshoot
+			a8 := clampf(a, 255.0)
+			out[4*x], out[4*x+1], out[4*x+2], out[4*x+3] = clampf(r, float32(a8)), clampf(g, float32(a8)), clampf(b, float32(a8)), a8
+		}
+	}
+}
+
+// downsampleBox downsamples src into dst by averaging each n x n block.
+func downsampleBox(dst, src *image.RGBA, n int) {
+	width, height, nn := dst.Rect.Dx(), dst.Rect.Dy(), uint32(n*n)
+	sums := make([]uint32, 4*width)
+	for y := range height {
+		clear(sums)
+		for sy := y * n; sy < (y+1)*n; sy++ {
+			row := src.Pix[sy*src.Stride:]
+			for sx := range width * n {
+				i := 4 * (sx / n)
+				sums[i] += uint32(row[4*sx])
+				sums[i+1] += uint32(row[4*sx+1])
+				sums[i+2] += uint32(row[4*sx+2])
+				sums[i+3] += uint32(row[4*sx+3])
+			}
+		}
+		out := dst.Pix[y*dst.Stride:]
+		for i, v := range sums {
+			out[i] = uint8((v + nn/2) / nn)
+		}
+	}
+}
+
+// filterWeight are the normalized filter weights for an output pixel.
+type filterWeight struct {
+	start int
+	k     []float32
+}
+
+// filterWeights calculates the filter weights for each of the n output
+// pixels, downsampling from size source pixels by the scale.
+func filterWeights(n, scale, size int, filter Filter) []filterWeight {
+	weights := make([]filterWeight, n)
+	radius := filter.support() * float64(scale)
+	for i := range weights {
+		center := (float64(i)+0.5)*float64(scale) - 0.5
+		start := max(int(math.Ceil(center-radius)), 0)
+		end := min(int(math.Floor(center+radius)), size-1)
+		k, sum := make([]float32, 0, end-start+1), 0.0
+		for j := start; j <= end; j++ {
+			v := filter.kernel((float64(j) - center) / float64(scale))
+			k, sum = append(k, float32(v)), sum+v
+		}
+		if sum != 0.0 {
+			for j := range k {
+				k[j] = float32(float64(k[j]) / sum)
+			}
+		}
+		weights[i] = filterWeight{start: start, k: k}
+	}
+	return weights
+}
+
+// clampf rounds and clamps v to [0, limit].
+func clampf(v, limit float32) uint8 {
+	return uint8(min(max(v+0.5, 0.0), limit))
+}
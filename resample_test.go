@@ -0,0 +1,75 @@
+package resvg
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestDownsample(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for y := range 4 {
+		for x := range 4 {
+			if (x+y)%2 == 0 {
+				src.SetRGBA(x, y, color.RGBA{0xff, 0xff, 0xff, 0xff})
+			}
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	downsample(dst, src, 2, FilterBox)
+	for i, v := range dst.Pix {
+		if v != 0x80 {
+			t.Fatalf("expected pixel %d to be %d, got: %d", i, 0x80, v)
+		}
+	}
+	solid := color.RGBA{0x40, 0x20, 0x10, 0x80}
+	for _, filter := range []Filter{FilterBox, FilterBilinear, FilterLanczos} {
+		src := image.NewRGBA(image.Rect(0, 0, 24, 12))
+		for i := 0; i < len(src.Pix); i += 4 {
+			src.Pix[i], src.Pix[i+1], src.Pix[i+2], src.Pix[i+3] = solid.R, solid.G, solid.B, solid.A
+		}
+		dst := image.NewRGBA(image.Rect(0, 0, 8, 4))
+		downsample(dst, src, 3, filter)
+		for y := range 4 {
+			for x := range 8 {
+				if c := dst.RGBAAt(x, y); c != solid {
+					t.Fatalf("filter %d: expected %v at (%d, %d), got: %v", filter, solid, x, y, c)
+				}
+			}
+		}
+	}
+}
+
+func TestSupersample(t *testing.T) {
+	for _, filter := range []Filter{FilterBox, FilterBilinear, FilterLanczos} {
+		img, err := Render([]byte(rectSVG), WithWidth(32), WithHeight(32), WithSupersample(4), WithDownsampleFilter(filter))
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if b := img.Bounds(); b.Dx() != 32 || b.Dy() != 32 {
+			t.Fatalf("expected 32x32, got: %dx%d", b.Dx(), b.Dy())
+		}
+		for i := 0; i < len(img.Pix); i += 4 {
+			if img.Pix[i] > img.Pix[i+3] || img.Pix[i+1] > img.Pix[i+3] || img.Pix[i+2] > img.Pix[i+3] {
+				t.Fatalf("filter %d: expected valid premultiplied pixel, got: %v", filter, img.Pix[i:i+4])
+			}
+		}
+	}
+}
+
+func TestSupersampleInvalid(t *testing.T) {
+	tests := []struct {
+		opts []Option
+		exp  error
+	}{
+		{[]Option{WithSupersample(-1)}, ErrInvalidSupersample},
+		{[]Option{WithSupersample(MaxSupersample + 1)}, ErrInvalidSupersample},
+		{[]Option{WithWidth(1 << 30), WithHeight(1 << 30), WithSupersample(MaxSupersample)}, ErrImageTooLarge},
+	}
+	for i, test := range tests {
+		if _, err := Render([]byte(rectSVG), test.opts...); !errors.Is(err, test.exp) {
+			t.Errorf("test %d expected %v, got: %v", i, test.exp, err)
+		}
+	}
+}
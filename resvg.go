@@ -23,38 +23,33 @@ char* version() {
 	strncpy(s, RESVG_VERSION, sizeof(RESVG_VERSION));
 	return s;
 }
-
-resvg_render_tree* parse(_GoBytes_ data, resvg_options* opts) {
-	// parse
-	resvg_render_tree* tree;
-	errno = resvg_parse_tree_from_data(data.p, data.n, opts, &tree);
-	if (errno != 0) {
-		return 0;
-	}
-	return tree;
-}
-
-void render(resvg_render_tree* tree, int width, int height, resvg_transform ts, _GoBytes_ buf) {
-	resvg_render(tree, ts, width, height, buf.p);
-}
 */
 import "C"
 
 import (
+	"context"
 	"fmt"
 	"image"
 	"image/color"
+	"image/draw"
 	"io"
+	"io/fs"
 	"math"
+	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 	"unsafe"
 )
 
 // Resvg wraps the [resvg c-api] to render svgs as standard a [image.RGBA].
 //
+// A Resvg is safe for concurrent use, and should be closed when no longer
+// needed.
+//
 // [resvg c-api]: https://github.com/RazrFalcon/resvg
 type Resvg struct {
 	loadSystemFonts bool
@@ -77,9 +72,33 @@ type Resvg struct {
 	width           uint
 	height          uint
 	scaleMode       ScaleMode
-	transform       []float32
-	opts            *C.resvg_options
+	align           Align
+	padding         [4]int
+	physicalWidth   float32
+	physicalHeight  float32
+	outputDPI       float32
+	quality         int
+	colors          int
+	dither          Dither
+	transform       *Transform
+	userTransform   *Transform
+	expandCanvas    bool
+	nodeStrokeBBox  bool
+	trim            bool
+	trimMargin      float32
+	emptyError      bool
+	timeout         time.Duration
+	startTimeout    time.Duration
+	memoryLimit     uint64
+	workerCommand   []string
+	noOptionsCache  bool
+	bufferPool      *BufferPool
+	supersample     int
+	filter          Filter
+	opts            *options
 	once            sync.Once
+	closed          bool
+	rw              sync.RWMutex
 }
 
 // New creates a new resvg.
@@ -100,86 +119,364 @@ func New(opts ...Option) *Resvg {
 
 // ParseConfig parses the svg, returning an image config.
 func (r *Resvg) ParseConfig(data []byte) (image.Config, error) {
-	tree, width, height, _, _, err := r.parse(data)
+	tree, err := r.Parse(data)
 	if err != nil {
 		return image.Config{}, err
 	}
-	// destroy
-	C.resvg_tree_destroy(tree)
-	return image.Config{
-		ColorModel: color.RGBAModel,
-		Width:      width,
-		Height:     height,
-	}, nil
+	defer tree.Close()
+	return tree.Config()
 }
 
 // Render renders svg data as a RGBA image.
 func (r *Resvg) Render(data []byte) (*image.RGBA, error) {
-	tree, width, height, scaleX, scaleY, err := r.parse(data)
+	tree, err := r.Parse(data)
 	if err != nil {
 		return nil, err
 	}
-	// build transform
-	ts := C.resvg_transform_identity()
-	if r.transform == nil {
-		ts.a, ts.d = C.float(scaleX), C.float(scaleY)
-	} else {
-		ts.a = C.float(r.transform[0])
-		ts.b = C.float(r.transform[1])
-		ts.c = C.float(r.transform[2])
-		ts.d = C.float(r.transform[3])
-		ts.e = C.float(r.transform[4])
-		ts.f = C.float(r.transform[5])
-	}
-	// background
-	img := image.NewRGBA(image.Rect(0, 0, int(width), int(height)))
-	if c := color.RGBAModel.Convert(r.background).(color.RGBA); c.R != 0 || c.G != 0 || c.B != 0 || c.A != 0 {
-		for i := range width {
-			for j := range height {
-				img.SetRGBA(i, j, c)
-			}
-		}
+	defer tree.Close()
+	return tree.Render()
+}
+
+// RenderInto renders svg data fitted into the rectangle of dst, compositing
+// it over dst's existing pixels. See [Tree.RenderInto].
+func (r *Resvg) RenderInto(dst draw.Image, rect image.Rectangle, data []byte) error {
+	tree, err := r.Parse(data)
+	if err != nil {
+		return err
 	}
-	// render
-	C.render(tree, C.int(width), C.int(height), ts, img.Pix)
-	// destroy
-	C.resvg_tree_destroy(tree)
-	return img, nil
+	defer tree.Close()
+	return tree.RenderInto(dst, rect)
+}
+
+// RenderTo renders svg data into img, reusing img's pixel buffer when it has
+// sufficient capacity. See [Tree.RenderTo].
+func (r *Resvg) RenderTo(img *image.RGBA, data []byte) (*image.RGBA, error) {
+	tree, err := r.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+	return tree.RenderTo(img)
 }
 
-// parse parses the svg data, returning the width, height, and scaling factors.
-func (r *Resvg) parse(data []byte) (*C.resvg_render_tree, int, int, float32, float32, error) {
+// ParseConfigContext parses the svg, returning an image config. Returns the
+// context's error when the context is done before parsing completes.
+func (r *Resvg) ParseConfigContext(ctx context.Context, data []byte) (image.Config, error) {
+	tree, err := r.parseContext(ctx, data)
+	if err != nil {
+		return image.Config{}, err
+	}
+	defer tree.Close()
+	return tree.Config()
+}
+
+// RenderContext renders svg data as a RGBA image. Returns the context's
+// error when the context is done before rendering completes.
+//
+// An abandoned parse or render continues in the background until it
+// completes, as cgo calls cannot be interrupted. Use [Isolated] to stop
+// in-flight renders.
+func (r *Resvg) RenderContext(ctx context.Context, data []byte) (*image.RGBA, error) {
+	tree, err := r.parseContext(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	// check between parse and render
+	if err := ctx.Err(); err != nil {
+		tree.Close()
+		return nil, err
+	}
+	return await(ctx, func() (*image.RGBA, error) {
+		defer tree.Close()
+		return tree.Render()
+	}, nil)
+}
+
+// parseContext parses the svg data, returning the context's error when the
+// context is done before parsing completes.
+func (r *Resvg) parseContext(ctx context.Context, data []byte) (*Tree, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return await(ctx, func() (*Tree, error) {
+		return r.Parse(data)
+	}, func(tree *Tree) {
+		tree.Close()
+	})
+}
+
+// RenderNode renders the node with the id in the svg data as a RGBA image,
+// cropped to the node's bounding box.
+func (r *Resvg) RenderNode(data []byte, id string) (*image.RGBA, error) {
+	tree, err := r.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+	return tree.RenderNode(id)
+}
+
+// Parse parses the svg data, returning a tree that can be rendered multiple
+// times. The returned tree should be closed when no longer needed.
+func (r *Resvg) Parse(data []byte) (*Tree, error) {
+	r.rw.RLock()
+	defer r.rw.RUnlock()
+	if r.closed {
+		return nil, ErrClosed
+	}
+	r.once.Do(r.buildOpts)
+	if r.opts == nil || r.opts.opts == nil {
+		return nil, ErrOptionsNotInitialized
+	}
+	tree, err := r.opts.parse(data, "")
+	if err != nil {
+		return nil, err
+	}
+	return newTree(r, tree), nil
+}
+
+// ParseFile parses the svg or svgz file, returning a tree that can be
+// rendered multiple times. Relative paths in the svg are resolved against
+// the file's directory, unless a resources dir has been set.
+//
+// Without a resources dir, files are parsed with shared options for the
+// file's directory (see [WithOptionsCache]). When the options cache is
+// disabled, the renderer's options are used, and the renderer's other parses
+// are blocked while parsing the file.
+func (r *Resvg) ParseFile(name string) (*Tree, error) {
+	// read before locking, resvg decompresses svgz data
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	r.rw.RLock()
+	defer r.rw.RUnlock()
+	if r.closed {
+		return nil, ErrClosed
+	}
 	r.once.Do(r.buildOpts)
-	if r.opts == nil {
-		return nil, 0, 0, 0.0, 0.0, ErrOptionsNotInitialized
+	if r.opts == nil || r.opts.opts == nil {
+		return nil, ErrOptionsNotInitialized
+	}
+	o, dir := r.opts, ""
+	if r.resourcesDir == "" {
+		if dir, err = filepath.Abs(filepath.Dir(name)); err != nil {
+			return nil, &fs.PathError{Op: "parse", Path: name, Err: err}
+		}
+		if !r.noOptionsCache {
+			o = acquireOptions(r.clone(WithResourcesDir(dir)))
+			defer o.release()
+			dir = ""
+		}
+	}
+	tree, err := o.parse(data, dir)
+	if err != nil {
+		return nil, &fs.PathError{Op: "parse", Path: name, Err: err}
+	}
+	return newTree(r, tree), nil
+}
+
+// ParseConfigFile parses the svg or svgz file, returning an image config.
+func (r *Resvg) ParseConfigFile(name string) (image.Config, error) {
+	tree, err := r.ParseFile(name)
+	if err != nil {
+		return image.Config{}, err
+	}
+	defer tree.Close()
+	return tree.Config()
+}
+
+// RenderFile renders the svg or svgz file as a RGBA image.
+func (r *Resvg) RenderFile(name string) (*image.RGBA, error) {
+	tree, err := r.ParseFile(name)
+	if err != nil {
+		return nil, err
 	}
-	// parse
-	tree, err := C.parse(data, r.opts)
+	defer tree.Close()
+	img, err := tree.Render()
 	if err != nil {
-		return nil, 0, 0, 0.0, 0.0, newErrNo(err)
+		return nil, &fs.PathError{Op: "render", Path: name, Err: err}
+	}
+	return img, nil
+}
+
+// clone creates a renderer with the parse and render settings of r, applying
+// opts.
+func (r *Resvg) clone(opts ...Option) *Resvg {
+	return r.derive(append([]Option{func(d *Resvg) {
+		d.loadSystemFonts = r.loadSystemFonts
+		d.resourcesDir = r.resourcesDir
+		d.fontFamily = r.fontFamily
+		d.fontSize = r.fontSize
+		d.serifFamily = r.serifFamily
+		d.sansSerifFamily = r.sansSerifFamily
+		d.cursiveFamily = r.cursiveFamily
+		d.fantasyFamily = r.fantasyFamily
+		d.monospaceFamily = r.monospaceFamily
+		d.languages = r.languages
+		d.shapeRendering = r.shapeRendering
+		d.textRendering = r.textRendering
+		d.imageRendering = r.imageRendering
+		d.fonts = r.fonts
+		d.fontFiles = r.fontFiles
+		d.noOptionsCache = r.noOptionsCache
+	}}, opts...)...)
+}
+
+// derive creates a renderer with the render settings of r, applying opts.
+// Options that affect parsing (fonts, resources dir, etc.) have no effect on
+// the derived renderer.
+func (r *Resvg) derive(opts ...Option) *Resvg {
+	if len(opts) == 0 {
+		return r
+	}
+	d := &Resvg{
+		dp:             r.dp,
+		background:     r.background,
+		width:          r.width,
+		height:         r.height,
+		scaleMode:      r.scaleMode,
+		align:          r.align,
+		padding:        r.padding,
+		physicalWidth:  r.physicalWidth,
+		physicalHeight: r.physicalHeight,
+		outputDPI:      r.outputDPI,
+		quality:        r.quality,
+		colors:         r.colors,
+		dither:         r.dither,
+		transform:      r.transform,
+		userTransform:  r.userTransform,
+		expandCanvas:   r.expandCanvas,
+		nodeStrokeBBox: r.nodeStrokeBBox,
+		trim:           r.trim,
+		trimMargin:     r.trimMargin,
+		emptyError:     r.emptyError,
+		bufferPool:     r.bufferPool,
+		supersample:    r.supersample,
+		filter:         r.filter,
 	}
-	// dimensions
-	size := C.resvg_get_image_size(tree)
-	if size.width == 0 || size.height == 0 {
-		return nil, 0, 0, 0.0, 0.0, ErrInvalidWidthOrHeight
+	for _, o := range opts {
+		o(d)
+	}
+	return d
+}
+
+// scale determines the width, height, and scaling factors for an image of
+// the passed size, excluding padding.
+func (r *Resvg) scale(w, h float32) (int, int, float32, float32, error) {
+	if uint(w) == 0 || uint(h) == 0 {
+		return 0, 0, 0.0, 0.0, ErrInvalidWidthOrHeight
+	}
+	// remove padding from the requested size
+	rw, rh := r.size()
+	if padX := uint(r.padding[1] + r.padding[3]); rw != 0 {
+		if rw <= padX {
+			return 0, 0, 0.0, 0.0, ErrInvalidWidth
+		}
+		rw -= padX
+	}
+	if padY := uint(r.padding[0] + r.padding[2]); rh != 0 {
+		if rh <= padY {
+			return 0, 0, 0.0, 0.0, ErrInvalidHeight
+		}
+		rh -= padY
 	}
 	// determine height, width, scaleX, scaleY
-	width, height, scaleX, scaleY := r.scaleMode.Scale(uint(size.width), uint(size.height), r.width, r.height)
+	width, height, scaleX, scaleY := r.scaleMode.Scale(uint(w), uint(h), rw, rh)
 	switch {
 	case width == 0:
-		return nil, 0, 0, 0.0, 0.0, ErrInvalidWidth
+		return 0, 0, 0.0, 0.0, ErrInvalidWidth
 	case height == 0:
-		return nil, 0, 0, 0.0, 0.0, ErrInvalidHeight
+		return 0, 0, 0.0, 0.0, ErrInvalidHeight
 	case scaleX == 0.0:
-		return nil, 0, 0, 0.0, 0.0, ErrInvalidXScale
+		return 0, 0, 0.0, 0.0, ErrInvalidXScale
 	case scaleY == 0.0:
-		return nil, 0, 0, 0.0, 0.0, ErrInvalidYScale
+		return 0, 0, 0.0, 0.0, ErrInvalidYScale
 	}
-	return tree, width, height, scaleX, scaleY, nil
+	return width, height, scaleX, scaleY, nil
 }
 
-// buildOpts builds the resvg options.
+// size returns the requested width and height in pixels, converting any
+// physical size using the output DPI.
+func (r *Resvg) size() (uint, uint) {
+	width, height := r.width, r.height
+	dpi := r.outputDPI
+	if dpi == 0.0 {
+		dpi = DefaultOutputDPI
+	}
+	if r.physicalWidth != 0.0 {
+		width = uint(math.Round(float64(r.physicalWidth * dpi)))
+	}
+	if r.physicalHeight != 0.0 {
+		height = uint(math.Round(float64(r.physicalHeight * dpi)))
+	}
+	return width, height
+}
+
+// OutputDPI returns the output DPI set with [WithOutputDPI] or
+// [WithPhysicalSize], or 0 when not set.
+func (r *Resvg) OutputDPI() float32 {
+	return r.outputDPI
+}
+
+// fit determines the output width, height and render transform for the
+// region, using the renderer's size, scale mode, alignment and padding.
+func (r *Resvg) fit(region Rect) (int, int, Transform, error) {
+	width, height, scaleX, scaleY, err := r.scale(region.Width, region.Height)
+	if err != nil {
+		return 0, 0, Transform{}, err
+	}
+	top, right, bottom, left := r.padding[0], r.padding[1], r.padding[2], r.padding[3]
+	// build transform
+	ts := Transform{
+		A: scaleX,
+		D: scaleY,
+		E: -scaleX*region.X + float32(left),
+		F: -scaleY*region.Y + float32(top),
+	}
+	if r.scaleMode == ScaleContain || r.scaleMode == ScaleCover {
+		// align the content within the canvas, cropping any overflow
+		alignX, alignY := r.align.factors()
+		ts.E += alignX * (float32(width) - scaleX*region.Width)
+		ts.F += alignY * (float32(height) - scaleY*region.Height)
+	}
+	if r.transform != nil {
+		ts = *r.transform
+	}
+	width, height = width+left+right, height+top+bottom
+	if r.userTransform != nil {
+		ts = r.userTransform.Multiply(ts)
+		if r.expandCanvas {
+			// grow the canvas to the transformed bounds, and move it to the
+			// origin, ignoring float error in the bounds
+			b := r.userTransform.bounds(Rect{Width: float32(width), Height: float32(height)})
+			x, y := math.Floor(float64(b.X)+1e-3), math.Floor(float64(b.Y)+1e-3)
+			width = int(math.Ceil(float64(b.X+b.Width)-1e-3) - x)
+			height = int(math.Ceil(float64(b.Y+b.Height)-1e-3) - y)
+			ts = Identity().Translate(float32(-x), float32(-y)).Multiply(ts)
+		}
+	}
+	if width <= 0 || height <= 0 {
+		return 0, 0, Transform{}, ErrInvalidWidthOrHeight
+	}
+	switch n := r.supersample; {
+	case n < 0 || n > MaxSupersample:
+		return 0, 0, Transform{}, ErrInvalidSupersample
+	case n > 1 && (width > math.MaxUint32/n || height > math.MaxUint32/n || width*n > math.MaxInt/4/(height*n)):
+		// supersampled buffer dimensions or size overflow
+		return 0, 0, Transform{}, ErrImageTooLarge
+	}
+	return width, height, ts, nil
+}
+
+// buildOpts builds the resvg options, or retrieves the shared resvg options
+// for the renderer's settings from the options cache.
 func (r *Resvg) buildOpts() {
+	r.opts = acquireOptions(r)
+}
+
+// createOpts creates the resvg options.
+func (r *Resvg) createOpts() *C.resvg_options {
 	opts := C.resvg_options_create()
 	if r.loadSystemFonts {
 		C.resvg_options_load_system_fonts(opts)
@@ -251,16 +548,31 @@ func (r *Resvg) buildOpts() {
 			C.free(unsafe.Pointer(s))
 		}
 	}
-	r.opts = opts
+	return opts
 }
 
-// finalize finalizes the C allocations.
-func (r *Resvg) finalize() {
+// Close releases the renderer's options and loaded fonts, waiting for any
+// in-progress parses to complete. After Close, the renderer returns
+// [ErrClosed]. Trees previously parsed by the renderer remain usable. Close
+// can safely be called multiple times.
+func (r *Resvg) Close() error {
+	r.rw.Lock()
+	defer r.rw.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
 	if r.opts != nil {
-		C.resvg_options_destroy(r.opts)
+		r.opts.release()
 	}
 	r.opts = nil
 	runtime.SetFinalizer(r, nil)
+	return nil
+}
+
+// finalize finalizes the C allocations.
+func (r *Resvg) finalize() {
+	_ = r.Close()
 }
 
 // ShapeRendering is the shape rendering mode.
@@ -295,6 +607,54 @@ const (
 	imageRenderingNotSet          ImageRendering = 0xff
 )
 
+// Rect is a rectangle.
+type Rect struct {
+	X      float32
+	Y      float32
+	Width  float32
+	Height float32
+}
+
+// newRect converts a resvg rect.
+func newRect(r C.resvg_rect) Rect {
+	return Rect{
+		X:      float32(r.x),
+		Y:      float32(r.y),
+		Width:  float32(r.width),
+		Height: float32(r.height),
+	}
+}
+
+// Transform is a 2D affine transform, with the same semantics as a svg
+// matrix(a, b, c, d, e, f) transform.
+type Transform struct {
+	A, B, C, D, E, F float32
+}
+
+// newTransform converts a resvg transform.
+func newTransform(ts C.resvg_transform) Transform {
+	return Transform{
+		A: float32(ts.a),
+		B: float32(ts.b),
+		C: float32(ts.c),
+		D: float32(ts.d),
+		E: float32(ts.e),
+		F: float32(ts.f),
+	}
+}
+
+// resvg converts the transform to a resvg transform.
+func (t Transform) resvg() C.resvg_transform {
+	return C.resvg_transform{
+		a: C.float(t.A),
+		b: C.float(t.B),
+		c: C.float(t.C),
+		d: C.float(t.D),
+		e: C.float(t.E),
+		f: C.float(t.F),
+	}
+}
+
 // ScaleMode is a scale mode.
 type ScaleMode uint8
 
@@ -306,6 +666,16 @@ const (
 	ScaleMaxWidth
 	ScaleMaxHeight
 	ScaleBestFit
+	// ScaleContain scales the image to fit within the width and height,
+	// preserving the aspect ratio, and outputs an image exactly the width and
+	// height. The image is aligned within the output by the alignment (see
+	// [WithAlign]), and the remaining area is filled with the background.
+	ScaleContain
+	// ScaleCover scales the image to completely fill the width and height,
+	// preserving the aspect ratio, and outputs an image exactly the width and
+	// height. The image is aligned within the output by the alignment (see
+	// [WithAlign]), and any overflow is cropped.
+	ScaleCover
 )
 
 // Scale calculates the scale for the width, height.
@@ -321,6 +691,10 @@ func (mode ScaleMode) Scale(width, height, w, h uint) (int, int, float32, float3
 		return scaleHeight(width, height, w, h, height > h)
 	case ScaleBestFit:
 		return scaleBestFit(width, height, w, h)
+	case ScaleContain:
+		return scaleContain(width, height, w, h)
+	case ScaleCover:
+		return scaleCover(width, height, w, h)
 	}
 	scaleX, scaleY := float32(1.0), float32(1.0)
 	if w != 0 {
@@ -364,6 +738,160 @@ func scaleBestFit(width, height, w, h uint) (int, int, float32, float32) {
 	return int(math.Round(float64(float32(width) * scale))), int(math.Round(float64(float32(height) * scale))), scale, scale
 }
 
+// scaleContain calculates the contain scale for the width, height.
+func scaleContain(width, height, w, h uint) (int, int, float32, float32) {
+	if w == 0 || h == 0 {
+		return scaleBestFit(width, height, w, h)
+	}
+	scale := min(float32(w)/float32(width), float32(h)/float32(height))
+	return int(w), int(h), scale, scale
+}
+
+// scaleCover calculates the cover scale for the width, height.
+func scaleCover(width, height, w, h uint) (int, int, float32, float32) {
+	if w == 0 || h == 0 {
+		return scaleBestFit(width, height, w, h)
+	}
+	scale := max(float32(w)/float32(width), float32(h)/float32(height))
+	return int(w), int(h), scale, scale
+}
+
+// Unit is a physical unit of length.
+type Unit uint8
+
+// Units.
+const (
+	UnitPixel Unit = iota
+	UnitMillimeter
+	UnitCentimeter
+	UnitInch
+	UnitPoint
+	UnitPica
+)
+
+// DefaultOutputDPI is the output DPI used to convert physical sizes when no
+// output DPI is set.
+const DefaultOutputDPI = 96.0
+
+// Inches converts v in the unit to inches. Pixels are converted using the
+// dpi.
+func (unit Unit) Inches(v, dpi float32) float32 {
+	switch unit {
+	case UnitMillimeter:
+		return v / 25.4
+	case UnitCentimeter:
+		return v / 2.54
+	case UnitInch:
+		return v
+	case UnitPoint:
+		return v / 72.0
+	case UnitPica:
+		return v / 6.0
+	}
+	if dpi == 0.0 {
+		dpi = DefaultOutputDPI
+	}
+	return v / dpi
+}
+
+// Pixels converts v in the unit to pixels at the dpi.
+func (unit Unit) Pixels(v, dpi float32) float32 {
+	if unit == UnitPixel {
+		return v
+	}
+	if dpi == 0.0 {
+		dpi = DefaultOutputDPI
+	}
+	return unit.Inches(v, dpi) * dpi
+}
+
+// String satisfies the [fmt.Stringer] interface.
+func (unit Unit) String() string {
+	switch unit {
+	case UnitMillimeter:
+		return "mm"
+	case UnitCentimeter:
+		return "cm"
+	case UnitInch:
+		return "in"
+	case UnitPoint:
+		return "pt"
+	case UnitPica:
+		return "pc"
+	}
+	return "px"
+}
+
+// Align is the alignment of a scaled image within the output image, similar
+// to the svg preserveAspectRatio attribute. The zero value is
+// [AlignXMidYMid].
+type Align uint8
+
+// Alignments.
+const (
+	AlignXMidYMid Align = iota
+	AlignXMinYMin
+	AlignXMidYMin
+	AlignXMaxYMin
+	AlignXMinYMid
+	AlignXMaxYMid
+	AlignXMinYMax
+	AlignXMidYMax
+	AlignXMaxYMax
+)
+
+// factors returns the x and y alignment factors, in the range [0, 1].
+func (align Align) factors() (float32, float32) {
+	switch align {
+	case AlignXMinYMin:
+		return 0.0, 0.0
+	case AlignXMidYMin:
+		return 0.5, 0.0
+	case AlignXMaxYMin:
+		return 1.0, 0.0
+	case AlignXMinYMid:
+		return 0.0, 0.5
+	case AlignXMaxYMid:
+		return 1.0, 0.5
+	case AlignXMinYMax:
+		return 0.0, 1.0
+	case AlignXMidYMax:
+		return 0.5, 1.0
+	case AlignXMaxYMax:
+		return 1.0, 1.0
+	}
+	return 0.5, 0.5
+}
+
+// await runs f in a goroutine, waiting for it to complete or for the context
+// to be done. When the context is done first, f is abandoned, and cleanup
+// (if any) is called with f's result once f completes.
+func await[T any](ctx context.Context, f func() (T, error), cleanup func(T)) (T, error) {
+	type result struct {
+		v   T
+		err error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		v, err := f()
+		ch <- result{v, err}
+	}()
+	select {
+	case res := <-ch:
+		return res.v, res.err
+	case <-ctx.Done():
+		if cleanup != nil {
+			go func() {
+				if res := <-ch; res.err == nil {
+					cleanup(res.v)
+				}
+			}()
+		}
+		var v T
+		return v, ctx.Err()
+	}
+}
+
 // Error is a package error.
 type Error string
 
@@ -375,6 +903,20 @@ const (
 	ErrInvalidHeight         Error = "invalid height"
 	ErrInvalidXScale         Error = "invalid x scale"
 	ErrInvalidYScale         Error = "invalid y scale"
+	ErrClosed                Error = "closed"
+	ErrNodeNotFound          Error = "node not found"
+	ErrEmptyImage            Error = "empty image"
+	ErrRenderTimeout         Error = "render timeout"
+	ErrWorkerCrashed         Error = "worker crashed"
+	ErrInvalidStride         Error = "invalid stride"
+	ErrUnknownFormat         Error = "unknown format"
+	ErrImageTooLarge         Error = "image too large"
+	ErrInvalidIconSize       Error = "invalid icon size"
+	ErrNoFrames              Error = "no frames"
+	ErrFrameSizeMismatch     Error = "frame size mismatch"
+	ErrInvalidFrame          Error = "invalid frame"
+	ErrParseOption           Error = "parse option not supported"
+	ErrInvalidSupersample    Error = "invalid supersample"
 )
 
 // Error satisfies the [error] interface.
@@ -546,6 +1088,59 @@ func WithHeight(height int) Option {
 	}
 }
 
+// WithPhysicalSize is a resvg option to set the width and height in a
+// physical unit. The pixel size is calculated using the output DPI (see
+// [WithOutputDPI]), and overrides the width and height set with [WithWidth]
+// and [WithHeight]. When no output DPI is set, it is set to
+// [DefaultOutputDPI]. A zero width or height is not set.
+func WithPhysicalSize(width, height float32, unit Unit) Option {
+	return func(r *Resvg) {
+		r.physicalWidth, r.physicalHeight = unit.Inches(width, 0), unit.Inches(height, 0)
+		if unit == UnitPixel {
+			// pixels are not physical
+			r.physicalWidth, r.physicalHeight = 0, 0
+			r.width, r.height = uint(width), uint(height)
+		}
+		if r.outputDPI == 0.0 && (r.physicalWidth != 0.0 || r.physicalHeight != 0.0) {
+			r.outputDPI = DefaultOutputDPI
+		}
+	}
+}
+
+// WithOutputDPI is a resvg option to set the output DPI, used to calculate
+// the pixel size for [WithPhysicalSize], and written by encoders supporting
+// it. Unlike [WithDPI], it does not affect unit conversion within the svg.
+func WithOutputDPI(dpi float32) Option {
+	return func(r *Resvg) {
+		r.outputDPI = dpi
+	}
+}
+
+// WithQuality is a resvg option to set the quality (1-100) used when encoding
+// lossy formats, such as [FormatJPEG].
+func WithQuality(quality int) Option {
+	return func(r *Resvg) {
+		r.quality = min(max(quality, 0), 100)
+	}
+}
+
+// WithColors is a resvg option to set the maximum number of palette colors
+// (at most 256) used when encoding paletted formats, such as [FormatGIF] and
+// [FormatSixel]. When 0, 256 colors are used.
+func WithColors(colors int) Option {
+	return func(r *Resvg) {
+		r.colors = colors
+	}
+}
+
+// WithDither is a resvg option to set the dithering method used when encoding
+// paletted formats, such as [FormatGIF].
+func WithDither(dither Dither) Option {
+	return func(r *Resvg) {
+		r.dither = dither
+	}
+}
+
 // WithScaleMode is a resvg option to set scale mode.
 func WithScaleMode(scaleMode ScaleMode) Option {
 	return func(r *Resvg) {
@@ -553,14 +1148,169 @@ func WithScaleMode(scaleMode ScaleMode) Option {
 	}
 }
 
-// WithTransform is a resvg option to set the transform used.
+// WithAlign is a resvg option to set the alignment of the image within the
+// output image, when using [ScaleContain] or [ScaleCover]. With [ScaleCover],
+// the alignment determines which part of the image is kept.
+func WithAlign(align Align) Option {
+	return func(r *Resvg) {
+		r.align = align
+	}
+}
+
+// WithPadding is a resvg option to set the padding, in pixels, added around
+// the rendered image. The padding is included in the width and height, and is
+// filled with the background.
+func WithPadding(top, right, bottom, left int) Option {
+	return func(r *Resvg) {
+		r.padding = [4]int{max(top, 0), max(right, 0), max(bottom, 0), max(left, 0)}
+	}
+}
+
+// WithTransform is a resvg option to set the transform used, replacing the
+// transform calculated for the scale mode. See [WithUserTransform] to combine
+// a transform with the scale mode.
 func WithTransform(a, b, c, d, e, f float32) Option {
 	return func(r *Resvg) {
-		r.transform = []float32{a, b, c, d, e, f}
+		r.transform = &Transform{a, b, c, d, e, f}
+	}
+}
+
+// WithUserTransform is a resvg option to apply a transform to the rendered
+// image, after the scale mode's transform, in output pixels.
+func WithUserTransform(ts Transform) Option {
+	return func(r *Resvg) {
+		r.userTransform = &ts
 	}
 }
 
-// Default is the default renderer.
+// WithExpandCanvas is a resvg option to grow the output image to the bounds
+// of the image with the user transform applied, so that the transformed image
+// is not clipped.
+func WithExpandCanvas(expandCanvas bool) Option {
+	return func(r *Resvg) {
+		r.expandCanvas = expandCanvas
+	}
+}
+
+// WithNodeStrokeBBox is a resvg option to crop rendered nodes to the node's
+// bounding box including stroke, instead of the node's fill bounding box.
+func WithNodeStrokeBBox(nodeStrokeBBox bool) Option {
+	return func(r *Resvg) {
+		r.nodeStrokeBBox = nodeStrokeBBox
+	}
+}
+
+// WithTrim is a resvg option to crop the rendered image to the bounding box
+// of the svg's drawn content. Rendering a svg with no drawn content returns
+// [ErrEmptyImage].
+func WithTrim(trim bool) Option {
+	return func(r *Resvg) {
+		r.trim = trim
+	}
+}
+
+// WithTrimMargin is a resvg option to set the margin, in svg canvas units,
+// added around the drawn content when trimming.
+func WithTrimMargin(trimMargin float32) Option {
+	return func(r *Resvg) {
+		r.trimMargin = trimMargin
+	}
+}
+
+// WithEmptyError is a resvg option to return [ErrEmptyImage] when rendering a
+// svg that has no renderable nodes, instead of a blank image.
+func WithEmptyError(emptyError bool) Option {
+	return func(r *Resvg) {
+		r.emptyError = emptyError
+	}
+}
+
+// WithTimeout is a resvg option to set the render timeout for an
+// [Isolated] renderer.
+func WithTimeout(timeout time.Duration) Option {
+	return func(r *Resvg) {
+		r.timeout = timeout
+	}
+}
+
+// DefaultStartTimeout is the timeout for starting an [Isolated] renderer's
+// workers when no start timeout is set.
+const DefaultStartTimeout = 30 * time.Second
+
+// WithStartTimeout is a resvg option to set the timeout for starting an
+// [Isolated] renderer's workers, which includes loading fonts. The start
+// timeout is separate from the render timeout.
+func WithStartTimeout(startTimeout time.Duration) Option {
+	return func(r *Resvg) {
+		r.startTimeout = startTimeout
+	}
+}
+
+// WithMemoryLimit is a resvg option to set the data memory limit
+// (RLIMIT_DATA), in bytes, of an [Isolated] renderer's workers. Only
+// supported on unix systems.
+func WithMemoryLimit(memoryLimit uint64) Option {
+	return func(r *Resvg) {
+		r.memoryLimit = memoryLimit
+	}
+}
+
+// WithWorkerCommand is a resvg option to set the command used to start an
+// [Isolated] renderer's workers. The command's binary must import this
+// package.
+func WithWorkerCommand(name string, args ...string) Option {
+	return func(r *Resvg) {
+		r.workerCommand = append([]string{name}, args...)
+	}
+}
+
+// WithOptionsCache is a resvg option to enable or disable sharing loaded
+// fonts and options with other renderers that have the same settings.
+// Enabled by default. A few recently used options are kept loaded after the
+// last renderer using them is closed. See [ClearOptionsCache].
+//
+// When enabled, [Resvg.ParseFile] without a resources dir shares options for
+// each file directory. When disabled, [Resvg.ParseFile] without a resources
+// dir uses the renderer's own options, blocking the renderer's other parses
+// while parsing the file.
+func WithOptionsCache(optionsCache bool) Option {
+	return func(r *Resvg) {
+		r.noOptionsCache = !optionsCache
+	}
+}
+
+// WithBufferPool is a resvg option to set a buffer pool used to allocate
+// rendered images. Rendered images can be returned to the pool with
+// [BufferPool.Put] when no longer needed.
+func WithBufferPool(bufferPool *BufferPool) Option {
+	return func(r *Resvg) {
+		r.bufferPool = bufferPool
+	}
+}
+
+// MaxSupersample is the maximum supersample factor.
+const MaxSupersample = 8
+
+// WithSupersample is a resvg option to render at n times the output size and
+// downsample with the downsample filter, improving anti-aliasing of thin
+// lines in small renders. Renders return [ErrInvalidSupersample] when n is
+// negative or greater than [MaxSupersample]; 0 and 1 disable supersampling.
+func WithSupersample(n int) Option {
+	return func(r *Resvg) {
+		r.supersample = n
+	}
+}
+
+// WithDownsampleFilter is a resvg option to set the filter used to downsample
+// supersampled renders.
+func WithDownsampleFilter(filter Filter) Option {
+	return func(r *Resvg) {
+		r.filter = filter
+	}
+}
+
+// Default is the default renderer, used by [Decode] and [DecodeConfig].
+// Default should not be closed.
 var Default = New()
 
 func init() {
@@ -570,7 +1320,48 @@ func init() {
 
 // Render renders svg data as a RGBA image.
 func Render(data []byte, opts ...Option) (*image.RGBA, error) {
-	return New(opts...).Render(data)
+	r := New(opts...)
+	defer r.Close()
+	return r.Render(data)
+}
+
+// RenderContext renders svg data as a RGBA image, returning the context's
+// error when the context is done before rendering completes.
+func RenderContext(ctx context.Context, data []byte, opts ...Option) (*image.RGBA, error) {
+	r := New(opts...)
+	// close without waiting for an abandoned parse to complete
+	defer func() { go r.Close() }()
+	return r.RenderContext(ctx, data)
+}
+
+// RenderNode renders the node with the id in the svg data as a RGBA image.
+func RenderNode(data []byte, id string, opts ...Option) (*image.RGBA, error) {
+	r := New(opts...)
+	defer r.Close()
+	return r.RenderNode(data, id)
+}
+
+// Parse parses svg data, returning a tree that can be rendered multiple
+// times.
+func Parse(data []byte, opts ...Option) (*Tree, error) {
+	r := New(opts...)
+	defer r.Close()
+	return r.Parse(data)
+}
+
+// ParseFile parses a svg or svgz file, returning a tree that can be rendered
+// multiple times.
+func ParseFile(name string, opts ...Option) (*Tree, error) {
+	r := New(opts...)
+	defer r.Close()
+	return r.ParseFile(name)
+}
+
+// RenderFile renders a svg or svgz file as a RGBA image.
+func RenderFile(name string, opts ...Option) (*image.RGBA, error) {
+	r := New(opts...)
+	defer r.Close()
+	return r.RenderFile(name)
 }
 
 // Version returns the resvg version.
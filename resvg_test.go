@@ -2,14 +2,19 @@ package resvg
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
 	_ "embed"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"image/png"
 	"io/fs"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -88,6 +93,113 @@ func testRender(t *testing.T, name string) {
 	}
 }
 
+func TestRenderFile(t *testing.T) {
+	data, err := os.ReadFile("testdata/rect.svg")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	buf := new(bytes.Buffer)
+	w := gzip.NewWriter(buf)
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	svgz := filepath.Join(t.TempDir(), "rect.svgz")
+	if err := os.WriteFile(svgz, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	for _, name := range []string{"testdata/rect.svg", svgz} {
+		img, err := RenderFile(name)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if size := img.Bounds().Size(); size.X != 400 || size.Y != 180 {
+			t.Errorf("expected 400x180, got: %dx%d", size.X, size.Y)
+		}
+		cfg, err := New().ParseConfigFile(name)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if cfg.Width != 400 || cfg.Height != 180 {
+			t.Errorf("expected 400x180, got: %dx%d", cfg.Width, cfg.Height)
+		}
+	}
+	name := filepath.Join("testdata", "missing.svg")
+	_, err = RenderFile(name)
+	var pathErr *fs.PathError
+	switch {
+	case !errors.As(err, &pathErr):
+		t.Fatalf("expected *fs.PathError, got: %v", err)
+	case pathErr.Path != name:
+		t.Errorf("expected path %q, got: %q", name, pathErr.Path)
+	}
+}
+
+func TestRenderContext(t *testing.T) {
+	r := New()
+	img, err := r.RenderContext(context.Background(), rectSVG)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if size := img.Bounds().Size(); size.X != 400 || size.Y != 180 {
+		t.Errorf("expected 400x180, got: %dx%d", size.X, size.Y)
+	}
+	cfg, err := r.ParseConfigContext(context.Background(), rectSVG)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.Width != 400 || cfg.Height != 180 {
+		t.Errorf("expected 400x180, got: %dx%d", cfg.Width, cfg.Height)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := r.RenderContext(ctx, rectSVG); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got: %v", err)
+	}
+	if _, err := r.ParseConfigContext(ctx, rectSVG); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got: %v", err)
+	}
+}
+
+func TestClose(t *testing.T) {
+	r := New(WithOptionsCache(false))
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 10 {
+				if _, err := r.Render(rectSVG); err != nil && !errors.Is(err, ErrClosed) {
+					t.Errorf("expected no error or ErrClosed, got: %v", err)
+				}
+			}
+		}()
+	}
+	tree, err := r.Parse(rectSVG)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer tree.Close()
+	if err := r.Close(); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	wg.Wait()
+	if err := r.Close(); err != nil {
+		t.Fatalf("expected no error on second close, got: %v", err)
+	}
+	if _, err := r.Render(rectSVG); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got: %v", err)
+	}
+	if _, err := r.ParseConfig(rectSVG); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got: %v", err)
+	}
+	if _, err := tree.Render(); err != nil {
+		t.Errorf("expected no error rendering tree after close, got: %v", err)
+	}
+}
+
 func TestScale(t *testing.T) {
 	tests := []struct {
 		mode   ScaleMode
@@ -122,6 +234,12 @@ func TestScale(t *testing.T) {
 		{ScaleBestFit, 16, 16, 200, 0, 200, 200, 12.5, 12.5},
 		{ScaleBestFit, 200, 200, 0, 16, 16, 16, 0.08, 0.08},
 		{ScaleBestFit, 250, 200, 0, 90, 113, 90, 0.45, 0.45},
+		{ScaleContain, 100, 100, 200, 100, 200, 100, 1.0, 1.0},
+		{ScaleContain, 400, 180, 256, 256, 256, 256, 0.64, 0.64},
+		{ScaleContain, 250, 200, 100, 0, 100, 80, 0.4, 0.4},
+		{ScaleCover, 100, 100, 200, 100, 200, 100, 2.0, 2.0},
+		{ScaleCover, 400, 180, 90, 90, 90, 90, 0.5, 0.5},
+		{ScaleCover, 250, 200, 0, 40, 50, 40, 0.2, 0.2},
 	}
 	for _, test := range tests {
 		t.Run(fmt.Sprintf("%d_%d_%d_%d", test.width, test.height, test.w, test.h), func(t *testing.T) {
@@ -150,3 +268,40 @@ func cleanString(s string) string {
 //
 //go:embed version.txt
 var versionTxt []byte
+
+func TestUnit(t *testing.T) {
+	tests := []struct {
+		unit Unit
+		v    float32
+		dpi  float32
+		exp  float32
+	}{
+		{UnitPixel, 10, 300, 10},
+		{UnitInch, 2, 300, 600},
+		{UnitMillimeter, 25.4, 300, 300},
+		{UnitCentimeter, 2.54, 150, 150},
+		{UnitPoint, 72, 0, 96},
+		{UnitPica, 6, 72, 72},
+	}
+	for _, test := range tests {
+		t.Run(test.unit.String(), func(t *testing.T) {
+			if v := test.unit.Pixels(test.v, test.dpi); math.Abs(float64(v-test.exp)) > 1e-3 {
+				t.Errorf("expected %f, got: %f", test.exp, v)
+			}
+		})
+	}
+	r := New(WithPhysicalSize(62, 0, UnitMillimeter), WithOutputDPI(300))
+	if dpi := r.OutputDPI(); dpi != 300 {
+		t.Errorf("expected output dpi 300, got: %f", dpi)
+	}
+	if w, h := r.size(); w != 732 || h != 0 {
+		t.Errorf("expected 732x0, got: %dx%d", w, h)
+	}
+	r = New(WithPhysicalSize(1, 0.5, UnitInch))
+	if dpi := r.OutputDPI(); dpi != DefaultOutputDPI {
+		t.Errorf("expected output dpi %f, got: %f", DefaultOutputDPI, dpi)
+	}
+	if w, h := r.size(); w != 96 || h != 48 {
+		t.Errorf("expected 96x48, got: %dx%d", w, h)
+	}
+}
@@ -0,0 +1,150 @@
+package resvg
+
+import (
+	"bufio"
+	"image"
+	"image/color"
+	"io"
+	"strconv"
+)
+
+// EncodeSixel encodes the image as a sixel escape sequence, using a palette
+// of up to colors colors (at most 256; when 0, 256 colors). The image is
+// flattened over the background, unless the background is transparent, in
+// which case mostly transparent pixels are left unpainted.
+func EncodeSixel(w io.Writer, img *image.RGBA, bg color.Color, colors int, dither Dither) error {
+	pal := toGIF(img, bg, colors, dither)
+	// transparent index
+	transparent := -1
+	for i, c := range pal.Palette {
+		if _, _, _, a := c.RGBA(); a == 0 {
+			transparent = i
+		}
+	}
+	width, height := pal.Rect.Dx(), pal.Rect.Dy()
+	bw := bufio.NewWriter(w)
+	// dcs with 1:1 aspect ratio and unpainted pixels left unchanged, and
+	// raster attributes
+	bw.WriteString("\x1bP0;1;0q\"1;1;")
+	bw.WriteString(strconv.Itoa(width) + ";" + strconv.Itoa(height))
+	for i, c := range pal.Palette {
+		if i == transparent {
+			continue
+		}
+		r, g, b, _ := c.RGBA()
+		// percent
+		bw.WriteString("#" + strconv.Itoa(i) + ";2;" +
+			strconv.Itoa(int((r*100+0x7fff)/0xffff)) + ";" +
+			strconv.Itoa(int((g*100+0x7fff)/0xffff)) + ";" +
+			strconv.Itoa(int((b*100+0x7fff)/0xffff)))
+	}
+	// bands of 6 rows, with the sixels for each color in the band
+	sixels := make([][]byte, len(pal.Palette))
+	used := make([]bool, len(pal.Palette))
+	for y := 0; y < height; y += 6 {
+		if y != 0 {
+			// next band
+			bw.WriteByte('-')
+		}
+		clear(used)
+		for dy := 0; dy < 6 && y+dy < height; dy++ {
+			row := pal.Pix[(y+dy)*pal.Stride:][:width]
+			for x, idx := range row {
+				if int(idx) == transparent {
+					continue
+				}
+				if !used[idx] {
+					if sixels[idx] == nil {
+						sixels[idx] = make([]byte, width)
+					}
+					clear(sixels[idx])
+					used[idx] = true
+				}
+				sixels[idx][x] |= 1 << dy
+			}
+		}
+		first := true
+		for idx, ok := range used {
+			if !ok {
+				continue
+			}
+			if !first {
+				// carriage return
+				bw.WriteByte('$')
+			}
+			first = false
+			bw.WriteString("#" + strconv.Itoa(idx))
+			writeSixels(bw, sixels[idx])
+		}
+	}
+	// st
+	bw.WriteString("\x1b\\")
+	return bw.Flush()
+}
+
+// writeSixels writes a row of sixels, run length encoding repeated sixels
+// and trimming trailing empty sixels.
+func writeSixels(bw *bufio.Writer, sixels []byte) {
+	end := len(sixels)
+	for end > 0 && sixels[end-1] == 0 {
+		end--
+	}
+	for i := 0; i < end; {
+		n := 1
+		for i+n < end && sixels[i+n] == sixels[i] {
+			n++
+		}
+		c := 63 + sixels[i]
+		if n > 3 {
+			bw.WriteString("!" + strconv.Itoa(n))
+			bw.WriteByte(c)
+		} else {
+			for range n {
+				bw.WriteByte(c)
+			}
+		}
+		i += n
+	}
+}
+
+// CellSize is the size, in pixels, of a terminal character cell.
+type CellSize struct {
+	Width  int
+	Height int
+}
+
+// DefaultCellSize is the cell size used when the terminal's cell size cannot
+// be determined.
+var DefaultCellSize = CellSize{Width: 10, Height: 20}
+
+// TerminalCellSize returns the cell size of the terminal for the file
+// descriptor, returning [DefaultCellSize] and false when it cannot be
+// determined.
+func TerminalCellSize(fd uintptr) (CellSize, bool) {
+	cols, rows, width, height, ok := terminalSize(fd)
+	if !ok || cols == 0 || rows == 0 || width == 0 || height == 0 {
+		return DefaultCellSize, false
+	}
+	return CellSize{Width: width / cols, Height: height / rows}, true
+}
+
+// WithCells is a resvg option to set the width and height to a number of
+// terminal character cells of the cell size. A zero cols or rows is not set.
+// Use with [ScaleBestFit] to fit within the cells while preserving the aspect
+// ratio.
+func WithCells(cols, rows int, cell CellSize) Option {
+	return func(r *Resvg) {
+		if cols > 0 {
+			r.width = uint(cols * cell.Width)
+		}
+		if rows > 0 {
+			r.height = uint(rows * cell.Height)
+		}
+	}
+}
+
+// RenderSixel renders svg data as a sixel escape sequence, using the
+// renderer's background, colors and dither settings.
+func (r *Resvg) RenderSixel(w io.Writer, data []byte) error {
+	return r.RenderEncode(w, data, FormatSixel)
+}
@@ -0,0 +1,82 @@
+package resvg
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestEncodeSixel(t *testing.T) {
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	draw4(img, red)
+	var buf bytes.Buffer
+	if err := EncodeSixel(&buf, img, color.White, 0, DitherNone); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if exp := "\x1bP0;1;0q\"1;1;2;2#0;2;100;0;0#0BB\x1b\\"; buf.String() != exp {
+		t.Errorf("expected %q, got: %q", exp, buf.String())
+	}
+	// run length encoding
+	img = image.NewRGBA(image.Rect(0, 0, 10, 1))
+	draw4(img, red)
+	buf.Reset()
+	if err := EncodeSixel(&buf, img, color.White, 0, DitherNone); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if s := buf.String(); !strings.Contains(s, "#0!10@\x1b") {
+		t.Errorf("expected run length encoded sixels, got: %q", s)
+	}
+	// transparency
+	img = image.NewRGBA(image.Rect(0, 0, 3, 1))
+	img.SetRGBA(0, 0, red)
+	img.SetRGBA(2, 0, red)
+	buf.Reset()
+	if err := EncodeSixel(&buf, img, color.Transparent, 0, DitherNone); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if s := buf.String(); !strings.Contains(s, ";2;100;0;0#") || !strings.HasSuffix(s, "@?@\x1b\\") || strings.Count(s, ";2;") != 1 {
+		t.Errorf("expected unpainted transparent pixel, got: %q", s)
+	}
+	// bands
+	img = image.NewRGBA(image.Rect(0, 0, 1, 13))
+	draw4(img, red)
+	buf.Reset()
+	if err := EncodeSixel(&buf, img, color.White, 0, DitherNone); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if s := buf.String(); !strings.HasSuffix(s, "#0~-#0~-#0@\x1b\\") {
+		t.Errorf("expected bands separated by graphics new lines, got: %q", s)
+	}
+}
+
+func TestRenderSixel(t *testing.T) {
+	var buf bytes.Buffer
+	cell := CellSize{Width: 8, Height: 16}
+	if err := New(WithScaleMode(ScaleBestFit), WithCells(10, 5, cell), WithColors(16)).RenderSixel(&buf, rectSVG); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if s := buf.String(); !strings.HasPrefix(s, "\x1bP0;1;0q\"1;1;80;36#") || !strings.HasSuffix(s, "\x1b\\") {
+		t.Errorf("expected 80x36 sixel, got: %q", s)
+	}
+	if strings.Count(buf.String(), ";2;") > 16 {
+		t.Errorf("expected at most 16 colors")
+	}
+	f, err := os.CreateTemp(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer f.Close()
+	if size, ok := TerminalCellSize(f.Fd()); ok || size != DefaultCellSize {
+		t.Errorf("expected default cell size for non-terminal, got: %v %t", size, ok)
+	}
+}
+
+func draw4(img *image.RGBA, c color.RGBA) {
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+}
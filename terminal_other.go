@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+
+package resvg
+
+// terminalSize is not supported on this system.
+func terminalSize(uintptr) (int, int, int, int, bool) {
+	return 0, 0, 0, 0, false
+}
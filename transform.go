@@ -0,0 +1,95 @@
+package resvg
+
+import (
+	"math"
+)
+
+// Identity returns the identity transform.
+func Identity() Transform {
+	return Transform{A: 1, D: 1}
+}
+
+// Multiply returns the transform multiplied by o. As with the svg transform
+// attribute, o is applied to points before t.
+func (t Transform) Multiply(o Transform) Transform {
+	return Transform{
+		A: t.A*o.A + t.C*o.B,
+		B: t.B*o.A + t.D*o.B,
+		C: t.A*o.C + t.C*o.D,
+		D: t.B*o.C + t.D*o.D,
+		E: t.A*o.E + t.C*o.F + t.E,
+		F: t.B*o.E + t.D*o.F + t.F,
+	}
+}
+
+// Translate returns the transform with a translation by tx, ty applied.
+func (t Transform) Translate(tx, ty float32) Transform {
+	return t.Multiply(Transform{A: 1, D: 1, E: tx, F: ty})
+}
+
+// Scale returns the transform with a scale by sx, sy applied.
+func (t Transform) Scale(sx, sy float32) Transform {
+	return t.Multiply(Transform{A: sx, D: sy})
+}
+
+// Rotate returns the transform with a clockwise rotation by angle degrees
+// about the origin applied.
+func (t Transform) Rotate(angle float32) Transform {
+	sin, cos := math.Sincos(float64(angle) * math.Pi / 180)
+	return t.Multiply(Transform{A: float32(cos), B: float32(sin), C: float32(-sin), D: float32(cos)})
+}
+
+// Skew returns the transform with a skew by ax, ay degrees along the x and y
+// axes applied.
+func (t Transform) Skew(ax, ay float32) Transform {
+	return t.Multiply(Transform{
+		A: 1,
+		B: float32(math.Tan(float64(ay) * math.Pi / 180)),
+		C: float32(math.Tan(float64(ax) * math.Pi / 180)),
+		D: 1,
+	})
+}
+
+// Invert returns the inverse of the transform. Returns false when the
+// transform is not invertible.
+func (t Transform) Invert() (Transform, bool) {
+	det := float64(t.A)*float64(t.D) - float64(t.B)*float64(t.C)
+	if det == 0 || math.IsNaN(det) || math.IsInf(det, 0) {
+		return Transform{}, false
+	}
+	a, b, c, d := float64(t.D)/det, -float64(t.B)/det, -float64(t.C)/det, float64(t.A)/det
+	return Transform{
+		A: float32(a),
+		B: float32(b),
+		C: float32(c),
+		D: float32(d),
+		E: float32(-a*float64(t.E) - c*float64(t.F)),
+		F: float32(-b*float64(t.E) - d*float64(t.F)),
+	}, true
+}
+
+// Apply applies the transform to the point x, y.
+func (t Transform) Apply(x, y float32) (float32, float32) {
+	return t.A*x + t.C*y + t.E, t.B*x + t.D*y + t.F
+}
+
+// IsIdentity returns true when the transform is the identity transform.
+func (t Transform) IsIdentity() bool {
+	return t == Identity()
+}
+
+// bounds returns the bounding box of the rect with the transform applied.
+func (t Transform) bounds(rect Rect) Rect {
+	minX, minY := float32(math.Inf(1)), float32(math.Inf(1))
+	maxX, maxY := float32(math.Inf(-1)), float32(math.Inf(-1))
+	for _, p := range [4][2]float32{
+		{rect.X, rect.Y},
+		{rect.X + rect.Width, rect.Y},
+		{rect.X, rect.Y + rect.Height},
+		{rect.X + rect.Width, rect.Y + rect.Height},
+	} {
+		x, y := t.Apply(p[0], p[1])
+		minX, minY, maxX, maxY = min(minX, x), min(minY, y), max(maxX, x), max(maxY, y)
+	}
+	return Rect{X: minX, Y: minY, Width: maxX - minX, Height: maxY - minY}
+}
@@ -0,0 +1,87 @@
+package resvg
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTransform(t *testing.T) {
+	ts := Identity().Translate(10, 20).Rotate(90).Scale(2, 3)
+	tests := []struct {
+		x, y   float32
+		ex, ey float32
+	}{
+		{0, 0, 10, 20},
+		{1, 0, 10, 22},
+		{0, 1, 7, 20},
+		{5, 5, -5, 30},
+	}
+	for i, test := range tests {
+		x, y := ts.Apply(test.x, test.y)
+		if !near(x, test.ex) || !near(y, test.ey) {
+			t.Errorf("test %d expected (%f, %f), got: (%f, %f)", i, test.ex, test.ey, x, y)
+		}
+	}
+	inv, ok := ts.Invert()
+	if !ok {
+		t.Fatalf("expected transform to be invertible")
+	}
+	if m := ts.Multiply(inv); !nearTransform(m, Identity()) {
+		t.Errorf("expected identity, got: %v", m)
+	}
+	if _, ok := (Transform{A: 1, B: 2, C: 2, D: 4}).Invert(); ok {
+		t.Errorf("expected transform to not be invertible")
+	}
+	if x, y := Identity().Skew(45, 0).Apply(0, 10); !near(x, 10) || !near(y, 10) {
+		t.Errorf("expected (10, 10), got: (%f, %f)", x, y)
+	}
+	if !Identity().IsIdentity() || ts.IsIdentity() {
+		t.Errorf("expected only identity to be identity")
+	}
+}
+
+func TestTreeUserTransform(t *testing.T) {
+	tree, err := Parse(rectSVG)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer tree.Close()
+	tests := []struct {
+		opts []Option
+		expw int
+		exph int
+	}{
+		{[]Option{WithUserTransform(Identity().Rotate(90))}, 400, 180},
+		{[]Option{WithUserTransform(Identity().Rotate(90)), WithExpandCanvas(true)}, 180, 400},
+		{[]Option{WithUserTransform(Identity().Rotate(45)), WithExpandCanvas(true)}, 411, 411},
+		{[]Option{WithUserTransform(Identity().Scale(0.5, 0.5)), WithExpandCanvas(true), WithScaleMode(ScaleBestFit), WithWidth(200)}, 100, 45},
+	}
+	for i, test := range tests {
+		img, err := tree.Render(test.opts...)
+		if err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		if size := img.Bounds().Size(); size.X != test.expw || size.Y != test.exph {
+			t.Errorf("test %d expected %dx%d, got: %dx%d", i, test.expw, test.exph, size.X, size.Y)
+		}
+	}
+	// rotated content is moved onto the expanded canvas
+	r := tree.r.derive(WithUserTransform(Identity().Rotate(90)), WithExpandCanvas(true))
+	tree.rw.RLock()
+	_, _, ts, err := tree.layout(r)
+	tree.rw.RUnlock()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if x, y := ts.Apply(0, 0); !near(x, 180) || !near(y, 0) {
+		t.Errorf("expected (180, 0), got: (%f, %f)", x, y)
+	}
+}
+
+func near(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-4
+}
+
+func nearTransform(a, b Transform) bool {
+	return near(a.A, b.A) && near(a.B, b.B) && near(a.C, b.C) && near(a.D, b.D) && near(a.E, b.E) && near(a.F, b.F)
+}
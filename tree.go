@@ -0,0 +1,236 @@
+package resvg
+
+/*
+#include "resvg.h"
+*/
+import "C"
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"runtime"
+	"sync"
+	"unsafe"
+)
+
+// Tree is a parsed svg render tree, that can be rendered multiple times with
+// different sizes, scale modes, and transforms.
+//
+// A tree is safe for concurrent use, and should be closed when no longer
+// needed.
+type Tree struct {
+	r    *Resvg
+	tree *C.resvg_render_tree
+	rw   sync.RWMutex
+}
+
+// newTree creates a new tree.
+func newTree(r *Resvg, tree *C.resvg_render_tree) *Tree {
+	t := &Tree{
+		r:    r,
+		tree: tree,
+	}
+	runtime.SetFinalizer(t, (*Tree).finalize)
+	return t
+}
+
+// Size returns the size of the svg, as defined by the svg's width and height
+// attributes.
+func (t *Tree) Size() (float32, float32) {
+	t.rw.RLock()
+	defer t.rw.RUnlock()
+	if t.tree == nil {
+		return 0.0, 0.0
+	}
+	size := C.resvg_get_image_size(t.tree)
+	return float32(size.width), float32(size.height)
+}
+
+// ViewBox returns the svg's view box.
+func (t *Tree) ViewBox() Rect {
+	t.rw.RLock()
+	defer t.rw.RUnlock()
+	if t.tree == nil {
+		return Rect{}
+	}
+	return newRect(C.resvg_get_image_viewbox(t.tree))
+}
+
+// BBox returns the bounding box of the svg's drawn content, in canvas
+// coordinates. Returns false when the svg has no drawn content.
+func (t *Tree) BBox() (Rect, bool) {
+	t.rw.RLock()
+	defer t.rw.RUnlock()
+	if t.tree == nil {
+		return Rect{}, false
+	}
+	var bbox C.resvg_rect
+	if !C.resvg_get_image_bbox(t.tree, &bbox) {
+		return Rect{}, false
+	}
+	return newRect(bbox), true
+}
+
+// IsEmpty returns true when the svg has no renderable nodes.
+func (t *Tree) IsEmpty() bool {
+	t.rw.RLock()
+	defer t.rw.RUnlock()
+	return t.tree == nil || bool(C.resvg_is_image_empty(t.tree))
+}
+
+// Config returns the image config for the tree, when rendered with the
+// tree's renderer settings and opts.
+func (t *Tree) Config(opts ...Option) (image.Config, error) {
+	t.rw.RLock()
+	defer t.rw.RUnlock()
+	if t.tree == nil {
+		return image.Config{}, ErrClosed
+	}
+	width, height, _, err := t.layout(t.r.derive(opts...))
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{
+		ColorModel: color.RGBAModel,
+		Width:      width,
+		Height:     height,
+	}, nil
+}
+
+// Render renders the tree as a RGBA image, using the tree's renderer
+// settings. Any passed options override the render settings (width, height,
+// scale mode, transform, and background) for this render only.
+func (t *Tree) Render(opts ...Option) (*image.RGBA, error) {
+	return t.RenderTo(nil, opts...)
+}
+
+// RenderTo renders the tree into img, reusing img's pixel buffer when it has
+// sufficient capacity, and otherwise allocating a new image. Returns the
+// rendered image, which should be used in place of img. When img is nil, the
+// image is retrieved from the renderer's buffer pool (see [WithBufferPool])
+// or allocated.
+func (t *Tree) RenderTo(img *image.RGBA, opts ...Option) (*image.RGBA, error) {
+	t.rw.RLock()
+	defer t.rw.RUnlock()
+	if t.tree == nil {
+		return nil, ErrClosed
+	}
+	r := t.r.derive(opts...)
+	width, height, ts, err := t.layout(r)
+	if err != nil {
+		return nil, err
+	}
+	img = r.image(img, width, height)
+	t.render(r, img, ts, nil)
+	return img, nil
+}
+
+// RenderInto renders the tree fitted into the rectangle of dst, compositing
+// the background and rendered svg over dst's existing pixels. The rectangle's
+// size overrides the width and height render settings, and the scale mode
+// determines how the svg is fitted. When the fitted svg is smaller than the
+// rectangle, it is aligned within the rectangle (see [WithAlign]).
+//
+// When dst is a [*image.RGBA] and the fitted svg spans dst's full width, the
+// svg is rendered directly into dst's pixels, as resvg renders contiguous
+// rows.
+func (t *Tree) RenderInto(dst draw.Image, rect image.Rectangle, opts ...Option) error {
+	t.rw.RLock()
+	defer t.rw.RUnlock()
+	if t.tree == nil {
+		return ErrClosed
+	}
+	r := t.r.derive(append(append([]Option(nil), opts...), WithWidth(rect.Dx()), WithHeight(rect.Dy()))...)
+	width, height, ts, err := t.layout(r)
+	if err != nil {
+		return err
+	}
+	// align within the rectangle
+	alignX, alignY := r.align.factors()
+	x := rect.Min.X + int(math.Round(float64(alignX)*float64(max(rect.Dx()-width, 0))))
+	y := rect.Min.Y + int(math.Round(float64(alignY)*float64(max(rect.Dy()-height, 0))))
+	rect = image.Rect(x, y, x+min(width, rect.Dx()), y+min(height, rect.Dy()))
+	if img, ok := dst.(*image.RGBA); ok && r.supersample <= 1 && rect.In(img.Rect) && rect.Dx() == width && rect.Dy() == height && rect.Min.X == img.Rect.Min.X && img.Stride == 4*width {
+		// fast path
+		c := color.RGBAModel.Convert(r.background).(color.RGBA)
+		if c.A != 0 {
+			draw.Draw(img, rect, image.NewUniform(c), image.Point{}, draw.Over)
+		}
+		C.resvg_render(t.tree, ts.resvg(), C.uint32_t(width), C.uint32_t(height), (*C.char)(unsafe.Pointer(&img.Pix[img.PixOffset(rect.Min.X, rect.Min.Y)])))
+		return nil
+	}
+	img := r.image(nil, width, height)
+	t.render(r, img, ts, nil)
+	draw.Draw(dst, rect, img, image.Point{}, draw.Over)
+	if r.bufferPool != nil {
+		r.bufferPool.Put(img)
+	}
+	return nil
+}
+
+// render renders the tree, or the node with the id when not nil, into img
+// with the transform, supersampling when enabled. Returns false when the node
+// could not be rendered. The tree must be read locked.
+func (t *Tree) render(r *Resvg, img *image.RGBA, ts Transform, id *C.char) bool {
+	width, height, n := img.Rect.Dx(), img.Rect.Dy(), r.supersample
+	dst := img
+	if n > 1 {
+		// render at n times the size, and downsample into img
+		width, height = width*n, height*n
+		ts = Transform{ts.A * float32(n), ts.B * float32(n), ts.C * float32(n), ts.D * float32(n), ts.E * float32(n), ts.F * float32(n)}
+		dst = r.image(nil, width, height)
+		if r.bufferPool != nil {
+			defer r.bufferPool.Put(dst)
+		}
+	}
+	pix := (*C.char)(unsafe.Pointer(&dst.Pix[0]))
+	if id == nil {
+		C.resvg_render(t.tree, ts.resvg(), C.uint32_t(width), C.uint32_t(height), pix)
+	} else if !C.resvg_render_node(t.tree, id, ts.resvg(), C.uint32_t(width), C.uint32_t(height), pix) {
+		return false
+	}
+	if n > 1 {
+		downsample(img, dst, n, r.filter)
+	}
+	return true
+}
+
+// layout determines the output width, height and render transform for the
+// renderer's settings. The tree must be read locked.
+func (t *Tree) layout(r *Resvg) (int, int, Transform, error) {
+	if r.emptyError && bool(C.resvg_is_image_empty(t.tree)) {
+		return 0, 0, Transform{}, ErrEmptyImage
+	}
+	size := C.resvg_get_image_size(t.tree)
+	region := Rect{Width: float32(size.width), Height: float32(size.height)}
+	if r.trim {
+		var bbox C.resvg_rect
+		if !C.resvg_get_image_bbox(t.tree, &bbox) {
+			return 0, 0, Transform{}, ErrEmptyImage
+		}
+		region = newRect(bbox)
+		region.X, region.Y = region.X-r.trimMargin, region.Y-r.trimMargin
+		region.Width = float32(math.Ceil(float64(region.Width + 2*r.trimMargin)))
+		region.Height = float32(math.Ceil(float64(region.Height + 2*r.trimMargin)))
+	}
+	return r.fit(region)
+}
+
+// Close destroys the tree. Close can safely be called multiple times.
+func (t *Tree) Close() error {
+	t.finalize()
+	return nil
+}
+
+// finalize finalizes the C allocations.
+func (t *Tree) finalize() {
+	t.rw.Lock()
+	defer t.rw.Unlock()
+	if t.tree != nil {
+		C.resvg_tree_destroy(t.tree)
+	}
+	t.tree = nil
+	runtime.SetFinalizer(t, nil)
+}
@@ -0,0 +1,244 @@
+package resvg
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestTree(t *testing.T) {
+	tree, err := Parse(rectSVG)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if w, h := tree.Size(); w != 400 || h != 180 {
+		t.Errorf("expected 400x180, got: %vx%v", w, h)
+	}
+	tests := []struct {
+		opts []Option
+		expw int
+		exph int
+	}{
+		{nil, 400, 180},
+		{[]Option{WithScaleMode(ScaleBestFit), WithWidth(800)}, 800, 360},
+		{[]Option{WithScaleMode(ScaleBestFit), WithHeight(90)}, 200, 90},
+		{[]Option{WithWidth(100), WithHeight(100)}, 100, 100},
+		{[]Option{WithScaleMode(ScaleBestFit), WithPhysicalSize(62, 0, UnitMillimeter), WithOutputDPI(300)}, 732, 329},
+		{[]Option{WithPhysicalSize(1, 0.5, UnitInch)}, 96, 48},
+	}
+	for i, test := range tests {
+		img, err := tree.Render(test.opts...)
+		if err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		if size := img.Bounds().Size(); size.X != test.expw || size.Y != test.exph {
+			t.Errorf("test %d expected %dx%d, got: %dx%d", i, test.expw, test.exph, size.X, size.Y)
+		}
+		cfg, err := tree.Config(test.opts...)
+		if err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		if cfg.Width != test.expw || cfg.Height != test.exph {
+			t.Errorf("test %d expected config %dx%d, got: %dx%d", i, test.expw, test.exph, cfg.Width, cfg.Height)
+		}
+	}
+	if err := tree.Close(); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := tree.Close(); err != nil {
+		t.Fatalf("expected no error on second close, got: %v", err)
+	}
+	if _, err := tree.Render(); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got: %v", err)
+	}
+}
+
+var rectSVG = []byte(`<?xml version="1.0" encoding="iso-8859-1"?>
+<svg width="400" height="180" xmlns="http://www.w3.org/2000/svg" version="1.1">
+  <rect id="box" x="50" y="20" width="150" height="150" style="fill:blue;stroke:pink;stroke-width:5;fill-opacity:0.1;stroke-opacity:0.9" />
+</svg>`)
+
+func TestTreeBounds(t *testing.T) {
+	tree, err := Parse(rectSVG)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer tree.Close()
+	if exp, viewBox := (Rect{0, 0, 400, 180}), tree.ViewBox(); viewBox != exp {
+		t.Errorf("expected view box %v, got: %v", exp, viewBox)
+	}
+	bbox, ok := tree.BBox()
+	if !ok {
+		t.Fatalf("expected bbox")
+	}
+	if exp := (Rect{50, 20, 150, 150}); bbox != exp {
+		t.Errorf("expected bbox %v, got: %v", exp, bbox)
+	}
+	if tree.IsEmpty() {
+		t.Errorf("expected tree to not be empty")
+	}
+	tests := []struct {
+		opts []Option
+		expw int
+		exph int
+	}{
+		{[]Option{WithTrim(true)}, 150, 150},
+		{[]Option{WithTrim(true), WithTrimMargin(5)}, 160, 160},
+		{[]Option{WithTrim(true), WithScaleMode(ScaleBestFit), WithWidth(75)}, 75, 75},
+	}
+	for i, test := range tests {
+		img, err := tree.Render(test.opts...)
+		if err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		if size := img.Bounds().Size(); size.X != test.expw || size.Y != test.exph {
+			t.Errorf("test %d expected %dx%d, got: %dx%d", i, test.expw, test.exph, size.X, size.Y)
+		}
+	}
+}
+
+func TestTreeEmpty(t *testing.T) {
+	tree, err := Parse([]byte(`<svg width="10" height="10" xmlns="http://www.w3.org/2000/svg"/>`))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer tree.Close()
+	if !tree.IsEmpty() {
+		t.Errorf("expected tree to be empty")
+	}
+	if _, ok := tree.BBox(); ok {
+		t.Errorf("expected no bbox")
+	}
+	if _, err := tree.Render(); err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+	if _, err := tree.Render(WithEmptyError(true)); !errors.Is(err, ErrEmptyImage) {
+		t.Errorf("expected ErrEmptyImage, got: %v", err)
+	}
+	if _, err := tree.Render(WithTrim(true)); !errors.Is(err, ErrEmptyImage) {
+		t.Errorf("expected ErrEmptyImage, got: %v", err)
+	}
+}
+
+func TestTreeRenderInto(t *testing.T) {
+	tree, err := Parse(redSVG)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer tree.Close()
+	white, red := color.RGBA{255, 255, 255, 255}, color.RGBA{255, 0, 0, 255}
+	tests := []struct {
+		dst  draw.Image
+		rect image.Rectangle
+		opts []Option
+		in   image.Rectangle
+	}{
+		{image.NewRGBA(image.Rect(0, 0, 100, 100)), image.Rect(10, 10, 60, 40), nil, image.Rect(10, 10, 60, 40)},
+		{image.NewRGBA(image.Rect(0, 0, 100, 100)), image.Rect(0, 20, 100, 60), nil, image.Rect(0, 20, 100, 60)},
+		{image.NewRGBA(image.Rect(0, 0, 100, 100)), image.Rect(10, 10, 60, 40), []Option{WithScaleMode(ScaleBestFit)}, image.Rect(20, 10, 50, 40)},
+		{image.NewRGBA(image.Rect(0, 0, 100, 100)), image.Rect(10, 10, 60, 40), []Option{WithScaleMode(ScaleBestFit), WithAlign(AlignXMinYMin)}, image.Rect(10, 10, 40, 40)},
+		{image.NewRGBA(image.Rect(0, 0, 100, 100)), image.Rect(10, 10, 60, 40), []Option{WithScaleMode(ScaleBestFit), WithAlign(AlignXMaxYMax)}, image.Rect(30, 10, 60, 40)},
+		{image.NewRGBA(image.Rect(0, 0, 100, 100)), image.Rect(0, 0, 100, 40), []Option{WithScaleMode(ScaleBestFit), WithAlign(AlignXMinYMin)}, image.Rect(0, 0, 40, 40)},
+		{image.NewRGBA(image.Rect(0, 0, 40, 100)), image.Rect(0, 0, 40, 100), []Option{WithScaleMode(ScaleBestFit)}, image.Rect(0, 30, 40, 70)},
+		{image.NewNRGBA(image.Rect(0, 0, 100, 100)), image.Rect(80, 80, 120, 120), nil, image.Rect(80, 80, 100, 100)},
+	}
+	for i, test := range tests {
+		draw.Draw(test.dst, test.dst.Bounds(), image.NewUniform(white), image.Point{}, draw.Src)
+		if err := tree.RenderInto(test.dst, test.rect, test.opts...); err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		b := test.dst.Bounds()
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				exp := white
+				if (image.Point{x, y}).In(test.in) {
+					exp = red
+				}
+				if c := color.RGBAModel.Convert(test.dst.At(x, y)); c != exp {
+					t.Fatalf("test %d expected %v at %d,%d, got: %v", i, exp, x, y, c)
+				}
+			}
+		}
+	}
+}
+
+var redSVG = []byte(`<svg width="10" height="10" xmlns="http://www.w3.org/2000/svg"><rect width="10" height="10" fill="#f00"/></svg>`)
+
+func TestTreeCanvas(t *testing.T) {
+	tree, err := Parse(redSVG)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer tree.Close()
+	red, blue := color.RGBA{0xff, 0, 0, 0xff}, color.RGBA{0, 0, 0xff, 0xff}
+	tests := []struct {
+		opts []Option
+		in   image.Point
+		out  image.Point
+	}{
+		{nil, image.Pt(20, 10), image.Pt(5, 10)},
+		{[]Option{WithAlign(AlignXMinYMid)}, image.Pt(5, 10), image.Pt(35, 10)},
+		{[]Option{WithAlign(AlignXMaxYMax)}, image.Pt(35, 10), image.Pt(5, 10)},
+		{[]Option{WithPadding(2, 2, 2, 2)}, image.Pt(13, 3), image.Pt(11, 1)},
+		{[]Option{WithPadding(0, 0, 0, 20), WithAlign(AlignXMinYMin)}, image.Pt(21, 1), image.Pt(19, 1)},
+	}
+	for i, test := range tests {
+		opts := append([]Option{WithScaleMode(ScaleContain), WithWidth(40), WithHeight(20), WithBackground(blue)}, test.opts...)
+		img, err := tree.Render(opts...)
+		if err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		if size := img.Bounds().Size(); size.X != 40 || size.Y != 20 {
+			t.Fatalf("test %d expected 40x20, got: %dx%d", i, size.X, size.Y)
+		}
+		if c := img.RGBAAt(test.in.X, test.in.Y); c != red {
+			t.Errorf("test %d expected %v at %v, got: %v", i, red, test.in, c)
+		}
+		if c := img.RGBAAt(test.out.X, test.out.Y); c != blue {
+			t.Errorf("test %d expected %v at %v, got: %v", i, blue, test.out, c)
+		}
+	}
+	if _, err := tree.Render(WithWidth(10), WithPadding(5, 5, 5, 5)); !errors.Is(err, ErrInvalidWidth) {
+		t.Errorf("expected ErrInvalidWidth, got: %v", err)
+	}
+}
+
+func TestTreeCover(t *testing.T) {
+	tree, err := Parse(rectSVG)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer tree.Close()
+	tests := []struct {
+		align Align
+		exp   Transform
+	}{
+		{AlignXMidYMid, Transform{A: 0.5, D: 0.5, E: -55}},
+		{AlignXMinYMin, Transform{A: 0.5, D: 0.5}},
+		{AlignXMaxYMax, Transform{A: 0.5, D: 0.5, E: -110}},
+	}
+	for i, test := range tests {
+		r := tree.r.derive(WithScaleMode(ScaleCover), WithWidth(90), WithHeight(90), WithAlign(test.align))
+		tree.rw.RLock()
+		width, height, ts, err := tree.layout(r)
+		tree.rw.RUnlock()
+		if err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		if width != 90 || height != 90 {
+			t.Errorf("test %d expected 90x90, got: %dx%d", i, width, height)
+		}
+		if ts != test.exp {
+			t.Errorf("test %d expected transform %v, got: %v", i, test.exp, ts)
+		}
+	}
+	img, err := tree.Render(WithScaleMode(ScaleCover), WithWidth(90), WithHeight(90))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if size := img.Bounds().Size(); size.X != 90 || size.Y != 90 {
+		t.Errorf("expected 90x90, got: %dx%d", size.X, size.Y)
+	}
+}
@@ -0,0 +1,406 @@
+package resvg
+
+import (
+	"encoding/binary"
+	"image"
+	"io"
+	"sort"
+)
+
+// EncodeWebP encodes the image as a lossless webp (VP8L), preserving alpha.
+func EncodeWebP(w io.Writer, img *image.RGBA) error {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	if width < 1 || height < 1 {
+		return ErrInvalidWidthOrHeight
+	}
+	if width > 1<<14 || height > 1<<14 {
+		return ErrImageTooLarge
+	}
+	// straight alpha argb pixels, with the subtract green transform applied
+	argb, alpha := make([]uint32, width*height), false
+	for y := range height {
+		row := img.Pix[img.PixOffset(img.Rect.Min.X, img.Rect.Min.Y+y):][:4*width]
+		for x := range width {
+			r, g, b, a := row[4*x], row[4*x+1], row[4*x+2], row[4*x+3]
+			switch a {
+			case 0:
+				r, g, b = 0, 0, 0
+			case 0xff:
+			default:
+				r, g, b = unpremultiply(r, a), unpremultiply(g, a), unpremultiply(b, a)
+			}
+			alpha = alpha || a != 0xff
+			argb[y*width+x] = uint32(a)<<24 | uint32(r-g)<<16 | uint32(g)<<8 | uint32(b-g)
+		}
+	}
+	// header
+	bw := new(bitWriter)
+	bw.bytes = append(bw.bytes, 0x2f)
+	bw.write(uint32(width-1), 14)
+	bw.write(uint32(height-1), 14)
+	bw.write(b2u(alpha), 1)
+	bw.write(0, 3) // version
+	// subtract green transform
+	bw.write(1, 1)
+	bw.write(2, 2)
+	bw.write(0, 1)
+	// no color cache, no meta prefix codes
+	bw.write(0, 1)
+	bw.write(0, 1)
+	// build the lz77 symbols and prefix codes
+	syms := webpSymbols(argb, width)
+	var hist [5][]uint32
+	for i, n := range [5]int{256 + 24, 256, 256, 256, 40} {
+		hist[i] = make([]uint32, n)
+	}
+	for _, s := range syms {
+		if s.length == 0 {
+			hist[0][s.argb>>8&0xff]++
+			hist[1][s.argb>>16&0xff]++
+			hist[2][s.argb&0xff]++
+			hist[3][s.argb>>24]++
+			continue
+		}
+		lp, _, _ := prefixEncode(s.length)
+		dp, _, _ := prefixEncode(s.dist)
+		hist[0][256+lp]++
+		hist[4][dp]++
+	}
+	var codes [5]prefixCode
+	for i := range codes {
+		codes[i] = newPrefixCode(hist[i], 15)
+		codes[i].writeTo(bw)
+	}
+	// image data
+	for _, s := range syms {
+		if s.length == 0 {
+			codes[0].writeSymbol(bw, int(s.argb>>8&0xff))
+			codes[1].writeSymbol(bw, int(s.argb>>16&0xff))
+			codes[2].writeSymbol(bw, int(s.argb&0xff))
+			codes[3].writeSymbol(bw, int(s.argb>>24))
+			continue
+		}
+		lp, lbits, lextra := prefixEncode(s.length)
+		codes[0].writeSymbol(bw, 256+lp)
+		bw.write(lextra, lbits)
+		dp, dbits, dextra := prefixEncode(s.dist)
+		codes[4].writeSymbol(bw, dp)
+		bw.write(dextra, dbits)
+	}
+	data := bw.flush()
+	// riff container
+	n := len(data)
+	hdr := make([]byte, 20)
+	copy(hdr[0:], "RIFF")
+	binary.LittleEndian.PutUint32(hdr[4:], uint32(12+n+n&1))
+	copy(hdr[8:], "WEBPVP8L")
+	binary.LittleEndian.PutUint32(hdr[16:], uint32(n))
+	if n&1 != 0 {
+		data = append(data, 0)
+	}
+	if _, err := w.Write(hdr); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// webpSymbol is a literal pixel, or a backward reference when length is not
+// 0. dist is the distance code.
+type webpSymbol struct {
+	argb   uint32
+	length int
+	dist   int
+}
+
+// webpSymbols encodes the pixels as literals and backward references to the
+// previous pixel or the pixel above.
+func webpSymbols(argb []uint32, width int) []webpSymbol {
+	const minLength, maxLength = 3, 4096
+	var syms []webpSymbol
+	for i := 0; i < len(argb); {
+		n := min(len(argb)-i, maxLength)
+		left, up := 0, 0
+		if i >= 1 {
+			for left < n && argb[i+left] == argb[i+left-1] {
+				left++
+			}
+		}
+		if i >= width && width > 1 {
+			for up < n && argb[i+up] == argb[i+up-width] {
+				up++
+			}
+		}
+		switch {
+		case max(left, up) < minLength:
+			syms = append(syms, webpSymbol{argb: argb[i]})
+			i++
+		case up > left:
+			// plane code for the pixel above
+			syms = append(syms, webpSymbol{length: up, dist: 1})
+			i += up
+		default:
+			// plane code for the pixel to the left
+			syms = append(syms, webpSymbol{length: left, dist: 2})
+			i += left
+		}
+	}
+	return syms
+}
+
+// prefixEncode returns the prefix symbol, extra bit count and extra bits for
+// a length or distance code.
+func prefixEncode(v int) (int, int, uint32) {
+	d := uint32(v - 1)
+	if d < 4 {
+		return int(d), 0, 0
+	}
+	h := 31
+	for d>>h == 0 {
+		h--
+	}
+	n := h - 1
+	return 2*h + int(d>>n&1), n, d & (1<<n - 1)
+}
+
+// codeLengthOrder is the order code length code lengths are written.
+var codeLengthOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// prefixCode is a canonical prefix (huffman) code.
+type prefixCode struct {
+	lengths []uint8
+	codes   []uint32
+	// single is the only symbol when the code has less than 2 symbols, which
+	// are written with 0 bits
+	single int
+}
+
+// newPrefixCode creates a prefix code for the histogram, with code lengths
+// up to maxBits.
+func newPrefixCode(hist []uint32, maxBits int) prefixCode {
+	var used []int
+	for i, n := range hist {
+		if n != 0 {
+			used = append(used, i)
+		}
+	}
+	if len(used) < 2 {
+		code := prefixCode{single: 0}
+		if len(used) == 1 {
+			code.single = used[0]
+		}
+		return code
+	}
+	lengths := huffmanLengths(hist, used, maxBits)
+	return prefixCode{lengths: lengths, codes: canonicalCodes(lengths), single: -1}
+}
+
+// writeSymbol writes the symbol's code.
+func (code prefixCode) writeSymbol(bw *bitWriter, sym int) {
+	if code.single == -1 {
+		bw.write(code.codes[sym], int(code.lengths[sym]))
+	}
+}
+
+// writeTo writes the code's lengths.
+func (code prefixCode) writeTo(bw *bitWriter) {
+	if code.single != -1 {
+		// simple code with a single symbol
+		bw.write(1, 1)
+		bw.write(0, 1)
+		if code.single < 2 {
+			bw.write(0, 1)
+			bw.write(uint32(code.single), 1)
+		} else {
+			bw.write(1, 1)
+			bw.write(uint32(code.single), 8)
+		}
+		return
+	}
+	bw.write(0, 1)
+	// run length encode the code lengths
+	type rle struct {
+		sym   int
+		extra uint32
+		bits  int
+	}
+	var tokens []rle
+	prev := uint8(8)
+	for i := 0; i < len(code.lengths); {
+		v, n := code.lengths[i], 1
+		for i+n < len(code.lengths) && code.lengths[i+n] == v {
+			n++
+		}
+		i += n
+		if v == 0 {
+			for ; n >= 11; n -= min(n, 138) {
+				tokens = append(tokens, rle{18, uint32(min(n, 138) - 11), 7})
+			}
+			if n >= 3 {
+				tokens, n = append(tokens, rle{17, uint32(n - 3), 3}), 0
+			}
+		} else if v != prev {
+			tokens, n, prev = append(tokens, rle{int(v), 0, 0}), n-1, v
+		}
+		if v != 0 {
+			for ; n >= 3; n -= min(n, 6) {
+				tokens = append(tokens, rle{16, uint32(min(n, 6) - 3), 2})
+			}
+		}
+		for ; n > 0; n-- {
+			tokens = append(tokens, rle{int(v), 0, 0})
+		}
+	}
+	// code length code
+	hist := make([]uint32, 19)
+	for _, t := range tokens {
+		hist[t.sym]++
+	}
+	clc := newPrefixCode(hist, 7)
+	lengths := make([]uint8, 19)
+	if clc.single != -1 {
+		// a single symbol code, with 0 bit symbols
+		lengths[clc.single] = 1
+	} else {
+		copy(lengths, clc.lengths)
+	}
+	n := 4
+	for i, sym := range codeLengthOrder {
+		if lengths[sym] != 0 {
+			n = max(n, i+1)
+		}
+	}
+	bw.write(uint32(n-4), 4)
+	for _, sym := range codeLengthOrder[:n] {
+		bw.write(uint32(lengths[sym]), 3)
+	}
+	// use the full alphabet
+	bw.write(0, 1)
+	for _, t := range tokens {
+		clc.writeSymbol(bw, t.sym)
+		bw.write(t.extra, t.bits)
+	}
+}
+
+// huffmanLengths calculates the huffman code lengths for the used symbols
+// in the histogram, limited to maxBits by flattening the histogram.
+func huffmanLengths(hist []uint32, used []int, maxBits int) []uint8 {
+	type node struct {
+		n           uint64
+		sym         int
+		left, right int
+	}
+	weights := make([]uint64, len(used))
+	for i, sym := range used {
+		weights[i] = uint64(hist[sym])
+	}
+	for {
+		nodes := make([]node, 0, 2*len(used))
+		for i, sym := range used {
+			nodes = append(nodes, node{weights[i], sym, -1, -1})
+		}
+		// queue of node indexes, sorted by weight
+		queue := make([]int, len(nodes))
+		for i := range queue {
+			queue[i] = i
+		}
+		sort.SliceStable(queue, func(i, j int) bool {
+			return nodes[queue[i]].n < nodes[queue[j]].n
+		})
+		for len(queue) > 1 {
+			a, b := queue[0], queue[1]
+			nodes = append(nodes, node{nodes[a].n + nodes[b].n, -1, a, b})
+			queue = queue[2:]
+			// insert the new node, keeping the queue sorted
+			k, i := len(nodes)-1, sort.Search(len(queue), func(i int) bool {
+				return nodes[queue[i]].n > nodes[len(nodes)-1].n
+			})
+			queue = append(queue, 0)
+			copy(queue[i+1:], queue[i:])
+			queue[i] = k
+		}
+		// assign depths
+		lengths, ok := make([]uint8, len(hist)), true
+		var walk func(int, int)
+		walk = func(i, depth int) {
+			if nodes[i].sym != -1 {
+				lengths[nodes[i].sym], ok = uint8(depth), ok && depth <= maxBits
+				return
+			}
+			walk(nodes[i].left, depth+1)
+			walk(nodes[i].right, depth+1)
+		}
+		walk(queue[0], 0)
+		if ok {
+			return lengths
+		}
+		for i := range weights {
+			weights[i] = weights[i]/2 + 1
+		}
+	}
+}
+
+// canonicalCodes returns the canonical codes for the code lengths, bit
+// reversed for writing least significant bit first.
+func canonicalCodes(lengths []uint8) []uint32 {
+	var count [16]uint32
+	for _, l := range lengths {
+		count[l]++
+	}
+	count[0] = 0
+	var next [16]uint32
+	for bits, code := 1, uint32(0); bits < 16; bits++ {
+		code = (code + count[bits-1]) << 1
+		next[bits] = code
+	}
+	codes := make([]uint32, len(lengths))
+	for sym, l := range lengths {
+		if l == 0 {
+			continue
+		}
+		code := next[l]
+		next[l]++
+		// reverse
+		var rev uint32
+		for range l {
+			rev, code = rev<<1|code&1, code>>1
+		}
+		codes[sym] = rev
+	}
+	return codes
+}
+
+// bitWriter writes bits least significant bit first.
+type bitWriter struct {
+	bytes []byte
+	bits  uint64
+	n     int
+}
+
+// write writes the low n bits of v.
+func (bw *bitWriter) write(v uint32, n int) {
+	bw.bits |= uint64(v) << bw.n
+	bw.n += n
+	for bw.n >= 8 {
+		bw.bytes = append(bw.bytes, byte(bw.bits))
+		bw.bits >>= 8
+		bw.n -= 8
+	}
+}
+
+// flush flushes any remaining bits, returning the written bytes.
+func (bw *bitWriter) flush() []byte {
+	if bw.n > 0 {
+		bw.bytes = append(bw.bytes, byte(bw.bits))
+		bw.bits, bw.n = 0, 0
+	}
+	return bw.bytes
+}
+
+// b2u converts a bool to a uint32.
+func b2u(b bool) uint32 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,77 @@
+package resvg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"testing"
+)
+
+func TestEncodeWebP(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderEncode(&buf, rectSVG, FormatWebP); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	b := buf.Bytes()
+	if string(b[:4]) != "RIFF" || string(b[8:16]) != "WEBPVP8L" {
+		t.Fatalf("expected webp header, got: %q", b[:16])
+	}
+	if n := binary.LittleEndian.Uint32(b[4:]); int(n) != len(b)-8 || len(b)%2 != 0 {
+		t.Errorf("expected riff size %d, got: %d", len(b)-8, n)
+	}
+	if b[20] != 0x2f {
+		t.Fatalf("expected VP8L signature, got: %x", b[20])
+	}
+	hdr := binary.LittleEndian.Uint32(b[21:])
+	if width, height := hdr&0x3fff+1, hdr>>14&0x3fff+1; width != 400 || height != 180 {
+		t.Errorf("expected 400x180, got: %dx%d", width, height)
+	}
+	buf.Reset()
+	if err := EncodeWebP(&buf, image.NewRGBA(image.Rect(0, 0, 3, 2))); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if hdr := binary.LittleEndian.Uint32(buf.Bytes()[21:]); hdr>>28&1 != 1 {
+		t.Errorf("expected alpha to be used")
+	}
+	if err := EncodeWebP(&buf, image.NewRGBA(image.Rect(0, 0, 1<<14+1, 1))); err != ErrImageTooLarge {
+		t.Errorf("expected ErrImageTooLarge, got: %v", err)
+	}
+}
+
+func TestPrefixEncode(t *testing.T) {
+	for v := 1; v <= 1<<20; v++ {
+		prefix, bits, extra := prefixEncode(v)
+		// decoding, as per the VP8L specification
+		exp := prefix + 1
+		if prefix >= 4 {
+			n := (prefix - 2) >> 1
+			if n != bits {
+				t.Fatalf("%d expected %d extra bits, got: %d", v, n, bits)
+			}
+			exp = (2+prefix&1)<<n + int(extra) + 1
+		}
+		if exp != v {
+			t.Fatalf("expected %d, got: %d", v, exp)
+		}
+	}
+}
+
+func TestHuffmanLengths(t *testing.T) {
+	// fibonacci weights produce a maximally deep tree
+	hist := make([]uint32, 40)
+	var used []int
+	for i, a, b := 0, uint32(1), uint32(1); i < len(hist); i, a, b = i+1, b, a+b {
+		hist[i], used = a, append(used, i)
+	}
+	lengths := huffmanLengths(hist, used, 15)
+	var kraft float64
+	for _, l := range lengths {
+		if l == 0 || l > 15 {
+			t.Fatalf("expected lengths in [1, 15], got: %d", l)
+		}
+		kraft += 1 / float64(uint(1)<<l)
+	}
+	if kraft != 1 {
+		t.Errorf("expected complete code, got kraft sum: %f", kraft)
+	}
+}